@@ -2,10 +2,13 @@ package config
 
 import (
 	"cmp"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/alx99/sail/internal/filesys"
 	"go.yaml.in/yaml/v3"
 )
 
@@ -19,6 +22,34 @@ type Settings struct {
 	Keymap    Keymap `yaml:"keymap"`
 	AltScreen bool   `yaml:"alt_screen"`
 	MinimalUI bool   `yaml:"minimal_ui"`
+	// UseTrash makes the delete key move files to the trash instead of
+	// deleting them permanently. Only trashed files can be restored with
+	// undo.
+	UseTrash bool `yaml:"use_trash"`
+	// ArchiveCopy makes copies keep symlinks, hard links, ownership,
+	// timestamps and extended attributes, like cp -a.
+	ArchiveCopy bool `yaml:"archive_copy"`
+	// VerifyCopy names the hash used to verify copies, either "sha256" or
+	// "xxhash". Copies are not verified if it is empty.
+	VerifyCopy string `yaml:"verify_copy"`
+	// Previewer is an executable that previews files instead of the
+	// built-in preview. It is called with the path of the file and the
+	// width and height of the pane.
+	Previewer string `yaml:"previewer"`
+	// PreviewerTimeout is how long the previewer may run before the
+	// built-in preview is shown instead.
+	PreviewerTimeout time.Duration `yaml:"previewer_timeout"`
+	Confirm          Confirm       `yaml:"confirm"`
+}
+
+// Confirm selects the operations that ask for confirmation before running.
+// Trashing or deleting selected files outside the current directory is
+// always confirmed, since they may have been selected in another instance.
+type Confirm struct {
+	// Delete covers permanent deletion, including purging the trash.
+	Delete    bool `yaml:"delete"`
+	Trash     bool `yaml:"trash"`
+	Overwrite bool `yaml:"overwrite"`
 }
 type Keymap struct {
 	NavUp            string `yaml:"up"`
@@ -27,9 +58,39 @@ type Keymap struct {
 	NavRight         string `yaml:"right"`
 	NavHome          string `yaml:"go_home"`
 	Delete           string `yaml:"delete"`
+	DeletePermanent  string `yaml:"delete_permanent"`
 	Select           string `yaml:"select"`
+	RangeSelect      string `yaml:"range_select"`
+	SelectAll        string `yaml:"select_all"`
+	InvertSelection  string `yaml:"invert_selection"`
+	SelectPattern    string `yaml:"select_pattern"`
+	DeselectPattern  string `yaml:"deselect_pattern"`
+	Search           string `yaml:"search"`
+	SearchNext       string `yaml:"search_next"`
+	SearchPrev       string `yaml:"search_prev"`
+	Find             string `yaml:"find"`
+	Grep             string `yaml:"grep"`
 	Cut              string `yaml:"cut"`
 	Copy             string `yaml:"copy"`
+	Paste            string `yaml:"paste"`
+	Symlink          string `yaml:"symlink"`
+	SymlinkRelative  string `yaml:"symlink_relative"`
+	Hardlink         string `yaml:"hardlink"`
+	Rename           string `yaml:"rename"`
+	BulkRename       string `yaml:"bulk_rename"`
+	CreateFile       string `yaml:"create_file"`
+	Mkdir            string `yaml:"mkdir"`
+	TrashView        string `yaml:"trash_view"`
+	TrashRestore     string `yaml:"trash_restore"`
+	TrashPurge       string `yaml:"trash_purge"`
+	TrashEmpty       string `yaml:"trash_empty"`
+	Undo             string `yaml:"undo"`
+	Redo             string `yaml:"redo"`
+	CancelJob        string `yaml:"cancel_job"`
+	JobPanel         string `yaml:"job_panel"`
+	JobRetry         string `yaml:"job_retry"`
+	JobDismiss       string `yaml:"job_dismiss"`
+	Compare          string `yaml:"compare"`
 	ToggleAltScreen  string `yaml:"toggle_alt_screen"`
 	ToggleParentPane string `yaml:"toggle_parent_pane"`
 	ToggleHidden     string `yaml:"toggle_hidden"`
@@ -48,16 +109,53 @@ func GetConfig() (Config, error) {
 				NavRight:         "l",
 				NavHome:          "~",
 				Delete:           "d",
+				DeletePermanent:  "D",
 				Select:           " ",
+				RangeSelect:      "v",
+				SelectAll:        "ctrl+a",
+				InvertSelection:  "*",
+				SelectPattern:    "+",
+				DeselectPattern:  "-",
+				Search:           "/",
+				SearchNext:       "n",
+				SearchPrev:       "N",
+				Find:             "F",
+				Grep:             "g",
 				Cut:              "x",
 				Copy:             "c",
+				Paste:            "p",
+				Symlink:          "s",
+				SymlinkRelative:  "S",
+				Hardlink:         "H",
+				Rename:           "r",
+				BulkRename:       "R",
+				CreateFile:       "a",
+				Mkdir:            "A",
+				TrashView:        "T",
+				TrashRestore:     "r",
+				TrashPurge:       "D",
+				TrashEmpty:       "E",
+				Undo:             "u",
+				Redo:             "U",
+				CancelJob:        "C",
+				JobPanel:         "J",
+				JobRetry:         "r",
+				JobDismiss:       "d",
+				Compare:          "=",
 				ToggleAltScreen:  "f",
 				ToggleParentPane: "P",
 				ToggleHidden:     ".",
 				ToggleMinimalUI:  "M",
 			},
-			AltScreen: true,
-			MinimalUI: false,
+			AltScreen:        true,
+			MinimalUI:        false,
+			UseTrash:         true,
+			PreviewerTimeout: 2 * time.Second,
+			Confirm: Confirm{
+				Delete:    true,
+				Trash:     false,
+				Overwrite: true,
+			},
 		},
 	}
 
@@ -76,7 +174,16 @@ func GetConfig() (Config, error) {
 		return Config{}, err
 	}
 
-	return cfg, yaml.Unmarshal(f, &cfg)
+	if err := yaml.Unmarshal(f, &cfg); err != nil {
+		return Config{}, err
+	}
+	if _, err := filesys.ParseHashAlgo(cfg.Settings.VerifyCopy); err != nil {
+		return Config{}, fmt.Errorf("verify_copy: %w", err)
+	}
+	if cfg.Settings.PreviewerTimeout <= 0 {
+		return Config{}, fmt.Errorf("previewer_timeout: must be positive, got %v", cfg.Settings.PreviewerTimeout)
+	}
+	return cfg, nil
 }
 
 // configPath returns the configuration file location
@@ -0,0 +1,65 @@
+package filesys
+
+import "slices"
+
+// ClipOp is what pasting the clipboard does with its paths.
+type ClipOp int
+
+const (
+	ClipCopy ClipOp = iota
+	ClipCut
+)
+
+func (o ClipOp) String() string {
+	if o == ClipCut {
+		return "cut"
+	}
+	return "copy"
+}
+
+// Clipboard holds the paths yanked or cut for a later paste. It is kept
+// apart from the Selection, so marking files does not change what is
+// pasted.
+type Clipboard struct {
+	op    ClipOp
+	paths []string
+	// onChange is called after every change, used to share the clipboard
+	onChange func()
+}
+
+func NewClipboard() *Clipboard {
+	return &Clipboard{}
+}
+
+// Set replaces the contents of the clipboard.
+func (c *Clipboard) Set(op ClipOp, paths []string) {
+	c.set(op, paths)
+	c.changed()
+}
+
+// Contents returns the operation and the paths in the clipboard.
+func (c *Clipboard) Contents() (ClipOp, []string) {
+	return c.op, slices.Clone(c.paths)
+}
+
+func (c *Clipboard) Clear() {
+	c.set(ClipCopy, nil)
+	c.changed()
+}
+
+// set replaces the contents without notifying onChange.
+func (c *Clipboard) set(op ClipOp, paths []string) {
+	c.op = op
+	c.paths = slices.Clone(paths)
+	slices.Sort(c.paths)
+}
+
+func (c *Clipboard) changed() {
+	if c.onChange != nil {
+		c.onChange()
+	}
+}
+
+func (c *Clipboard) Count() int {
+	return len(c.paths)
+}
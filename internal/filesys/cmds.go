@@ -1,7 +1,14 @@
 package filesys
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -54,33 +61,245 @@ func LoadChildCmd(reqID int, childPath string) tea.Cmd {
 	}
 }
 
+// SizeMsg carries the result of SizeCmd.
+type SizeMsg struct {
+	ReqID int
+	Size  int64
+}
+
+// SizeCmd calculates the combined size of the given paths.
+func SizeCmd(ctx context.Context, reqID int, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		size, err := PathsSize(ctx, paths)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		return SizeMsg{ReqID: reqID, Size: size}
+	}
+}
+
+// FilesComparedMsg carries the result of Jobs.CompareCmd.
+type FilesComparedMsg struct{ Comparison Comparison }
+
 type FilesDeletedMsg struct{ Paths []string }
 type FilesMovedMsg struct{ Paths []string }
 type FilesCopiedMsg struct{ Paths []string }
+type FilesLinkedMsg struct{ Paths []string }
+type FileRenamedMsg struct{ OldPath, NewPath string }
+type FilesRenamedMsg struct{ Renames []Rename }
+type FileCreatedMsg struct{ Path string }
+
+// NamesEditedMsg is sent when the editor opened by EditNamesCmd exits.
+type NamesEditedMsg struct {
+	Sources []string
+	Targets []string
+}
+
+func RenameCmd(j *Journal, path, newName string) tea.Cmd {
+	return func() tea.Msg {
+		newPath, err := RenamePath(path, newName)
+		if err != nil {
+			return err
+		}
+		if newPath != path {
+			j.Record("rename", []Op{{Kind: OpMove, Src: path, Dst: newPath}})
+		}
+		return FileRenamedMsg{OldPath: path, NewPath: newPath}
+	}
+}
+
+// EditNamesCmd writes the given paths to a temporary file, one per line, and
+// opens it in $VISUAL or $EDITOR. Paths inside base are written relative to it.
+func EditNamesCmd(base string, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		lines := make([]string, len(paths))
+		for i, path := range paths {
+			lines[i] = relativeTo(base, path)
+		}
+		name, err := writeTempLines("sail-rename-*.txt", lines)
+		if err != nil {
+			return err
+		}
+
+		editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+		c := exec.Command(editor[0], append(editor[1:], name)...)
+
+		// The message returned by the command tells bubbletea to run the
+		// editor, so it can be passed on from here
+		return tea.ExecProcess(c, func(err error) tea.Msg {
+			defer os.Remove(name)
+			if err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+
+			text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+			targets := strings.Split(text, "\n")
+			if len(targets) != len(paths) {
+				return errors.New("line count changed, aborting rename")
+			}
+			for i, target := range targets {
+				if strings.TrimSpace(target) == "" {
+					return fmt.Errorf("empty name on line %d, aborting rename", i+1)
+				}
+				if !filepath.IsAbs(target) {
+					targets[i] = filepath.Join(base, target)
+				}
+			}
+
+			return NamesEditedMsg{Sources: paths, Targets: targets}
+		})()
+	}
+}
+
+// writeTempLines writes lines to a new temporary file named after pattern
+// and returns its path.
+func writeTempLines(pattern string, lines []string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(strings.Join(lines, "\n") + "\n")
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// BulkRenameCmd renames every source to the target at the same index.
+func BulkRenameCmd(j *Journal, sources, targets []string) tea.Cmd {
+	return func() tea.Msg {
+		steps, err := PlanRenames(sources, targets)
+		if err != nil {
+			return err
+		}
+		if err := ApplyRenames(steps); err != nil {
+			return err
+		}
+
+		ops := make([]Op, len(steps))
+		for i, step := range steps {
+			ops[i] = Op{Kind: OpMove, Src: step.From, Dst: step.To}
+		}
+		j.Record("rename", ops)
+
+		renames := make([]Rename, 0, len(sources))
+		for i, src := range sources {
+			if filepath.Clean(src) != filepath.Clean(targets[i]) {
+				renames = append(renames, Rename{From: src, To: filepath.Clean(targets[i])})
+			}
+		}
+		return FilesRenamedMsg{Renames: renames}
+	}
+}
+
+// relativeTo returns path relative to base if it is located inside it.
+func relativeTo(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
 
-func DeleteCmd(paths []string) tea.Cmd {
+func CreateFileCmd(j *Journal, path string) tea.Cmd {
 	return func() tea.Msg {
-		if err := DeletePaths(paths); err != nil {
+		created, err := CreateFile(path)
+		j.Record("create", createOps(created, path))
+		if err != nil {
 			return err
 		}
-		return FilesDeletedMsg{Paths: paths}
+		return FileCreatedMsg{Path: path}
 	}
 }
 
-func MoveCmd(paths []string, dst string) tea.Cmd {
+func MkdirCmd(j *Journal, path string) tea.Cmd {
 	return func() tea.Msg {
-		if err := MovePaths(paths, dst); err != nil {
+		created, err := Mkdir(path)
+		j.Record("create", createOps(created, ""))
+		if err != nil {
+			return err
+		}
+		return FileCreatedMsg{Path: path}
+	}
+}
+
+// createOps turns created paths into ops. All paths are directories except
+// for file.
+func createOps(created []string, file string) []Op {
+	ops := make([]Op, len(created))
+	for i, path := range created {
+		ops[i] = Op{Kind: OpCreate, Dst: path, Dir: path != file}
+	}
+	return ops
+}
+
+type UndoneMsg struct{ Entry Entry }
+type RedoneMsg struct{ Entry Entry }
+
+func UndoCmd(j *Journal) tea.Cmd {
+	return func() tea.Msg {
+		e, err := j.Undo()
+		if err != nil {
+			return err
+		}
+		return UndoneMsg{Entry: e}
+	}
+}
+
+func RedoCmd(j *Journal) tea.Cmd {
+	return func() tea.Msg {
+		e, err := j.Redo()
+		if err != nil {
+			return err
+		}
+		return RedoneMsg{Entry: e}
+	}
+}
+
+type TrashListedMsg struct{ Entries []TrashEntry }
+type TrashRestoredMsg struct{ Paths []string }
+type TrashPurgedMsg struct{ Count int }
+
+func ListTrashCmd() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := ListTrash()
+		if err != nil {
+			return err
+		}
+		return TrashListedMsg{Entries: entries}
+	}
+}
+
+func RestoreTrashCmd(entries []TrashEntry) tea.Cmd {
+	return func() tea.Msg {
+		paths, err := RestoreTrash(entries)
+		if err != nil {
 			return err
 		}
-		return FilesMovedMsg{Paths: paths}
+		return TrashRestoredMsg{Paths: paths}
 	}
 }
 
-func CopyCmd(paths []string, dst string) tea.Cmd {
+// PurgeTrashCmd permanently deletes the given entries from the trash. Only
+// the entries that were shown are passed, so that emptying the trash does
+// not delete anything trashed since.
+func PurgeTrashCmd(entries []TrashEntry) tea.Cmd {
 	return func() tea.Msg {
-		if err := CopyPaths(paths, dst); err != nil {
+		if err := PurgeTrash(entries); err != nil {
 			return err
 		}
-		return FilesCopiedMsg{Paths: paths}
+		return TrashPurgedMsg{Count: len(entries)}
 	}
 }
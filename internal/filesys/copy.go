@@ -0,0 +1,450 @@
+package filesys
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CopyPaths copies paths into dst. If ctx is cancelled, the entry being
+// copied is removed and the ops for the entries that were finished are
+// returned along with the error. Copies that fail verification are removed
+// as well.
+func CopyPaths(ctx context.Context, paths []string, dst string, opts PasteOptions, p *Progress) ([]Op, error) {
+	c := newCopier(ctx, p, opts.Archive)
+	ops := make([]Op, 0, len(paths))
+	for _, path := range unique(paths) {
+		if err := ctx.Err(); err != nil {
+			return ops, err
+		}
+		target, r, ok, err := opts.resolve(path, copyTarget(path, dst))
+		if err != nil {
+			return ops, err
+		}
+		if !ok {
+			p.Handled(path)
+			continue
+		}
+
+		if err := c.copy(path, target); err != nil {
+			return ops, err
+		}
+		if opts.Verify != HashNone {
+			if err := verifyTree(ctx, opts.Verify, c.stat, path, target, p); err != nil {
+				if err2 := os.RemoveAll(target); err2 != nil {
+					slog.Error("Failed to remove unverified copy", "error", err2, "path", target)
+				}
+				return ops, err
+			}
+		}
+		placed, replaced, err := r.commit(target)
+		if err != nil {
+			if err2 := os.RemoveAll(target); err2 != nil {
+				slog.Error("Failed to remove copy", "error", err2, "path", target)
+			}
+			return ops, err
+		}
+		ops = append(ops, replaced...)
+		ops = append(ops, Op{Kind: OpCopy, Src: path, Dst: placed, Archive: opts.Archive, Stamp: stampCopy(placed)})
+		p.Handled(path)
+	}
+	return ops, nil
+}
+
+// copyTarget returns the path src is copied to when pasted into dstDir.
+// Copying an entry into its own directory adds a "_copy" suffix.
+func copyTarget(src, dstDir string) string {
+	return pasteTarget(src, dstDir, "_copy")
+}
+
+// pasteTarget returns the path of src inside dstDir, adding suffix to the
+// name if that is src itself.
+func pasteTarget(src, dstDir, suffix string) string {
+	dst := filepath.Join(dstDir, filepath.Base(src))
+	if filepath.Clean(dst) != filepath.Clean(src) {
+		return dst
+	}
+
+	if info, err := os.Stat(src); err == nil && info.IsDir() {
+		return dst + suffix
+	}
+	ext := filepath.Ext(dst)
+	return strings.TrimSuffix(dst, ext) + suffix + ext
+}
+
+// CopyAll copies src to dst, recursing into directories.
+func CopyAll(ctx context.Context, src, dst string) error {
+	return newCopier(ctx, nil, false).copy(src, dst)
+}
+
+// fileID identifies a file by its device and inode.
+type fileID struct {
+	dev, ino uint64
+}
+
+// copyWorkers is the number of files of a tree copied concurrently.
+const copyWorkers = 8
+
+// copier copies file trees. In archive mode symlinks are copied as links,
+// hard links between copied files are kept, and ownership, permissions,
+// extended attributes and timestamps are preserved where possible.
+type copier struct {
+	ctx     context.Context
+	p       *Progress
+	archive bool
+	links   map[fileID]string // first copy of each hard linked file
+}
+
+func newCopier(ctx context.Context, p *Progress, archive bool) *copier {
+	return &copier{ctx: ctx, p: p, archive: archive, links: make(map[fileID]string)}
+}
+
+// copyTask is a single entry of a tree copy.
+type copyTask struct {
+	src, dst string
+	info     os.FileInfo
+}
+
+// treePlan is the work left once the directories and symlinks of a tree
+// have been created.
+type treePlan struct {
+	dirs  []copyTask // parents before their children
+	files []copyTask // in walk order
+	links []copyTask // hard links to an earlier file, src is its copy
+}
+
+func (c *copier) copy(src, dst string) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	slog.Info("Copy", "src", src, "dst", dst)
+
+	info, err := c.stat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return c.copySymlink(src, dst, info)
+	case info.Mode()&os.ModeSocket != 0:
+		return fmt.Errorf("cannot copy socket %q", src)
+	case isSpecial(info):
+		return c.copySpecial(src, dst, info)
+	case !info.IsDir():
+		return c.copyFile(src, dst, info)
+	}
+	return c.copyTree(src, dst, info)
+}
+
+// isSpecial reports whether info describes a FIFO or a device, which are
+// recreated rather than read.
+func isSpecial(info os.FileInfo) bool {
+	return info.Mode()&(os.ModeNamedPipe|os.ModeDevice) != 0
+}
+
+func (c *copier) stat(path string) (os.FileInfo, error) {
+	if c.archive {
+		return os.Lstat(path)
+	}
+	return os.Stat(path)
+}
+
+// copyTree copies the directory src. The tree is walked first, creating
+// directories and symlinks, before its files are copied by a pool of
+// workers. On error the destination is removed again.
+func (c *copier) copyTree(src, dst string, info os.FileInfo) (err error) {
+	var plan treePlan
+	defer func() {
+		if err != nil && len(plan.dirs) > 0 { // if there was an error, attempt to clean up
+			if err2 := os.RemoveAll(dst); err2 != nil {
+				slog.Error("Failed to remove directory", "error", err2, "path", dst)
+			}
+		}
+	}()
+
+	if err = c.plan(&plan, src, dst, info); err != nil {
+		return err
+	}
+	if err = c.copyFiles(plan.files); err != nil {
+		return err
+	}
+
+	for _, l := range plan.links {
+		if err = os.Link(l.src, l.dst); err != nil {
+			return err
+		}
+		c.p.AddFiles(1)
+		c.p.AddBytes(l.info.Size())
+	}
+
+	if c.archive {
+		// Timestamps are set last since creating the entries changes
+		// them, and children go first so their parents stay writable
+		for i := len(plan.dirs) - 1; i >= 0; i-- {
+			d := plan.dirs[i]
+			if err = copyMetadata(d.src, d.dst, d.info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// plan walks the directory src, creating the directory structure, symlinks
+// and special files below dst and collecting the regular files to copy.
+// Sockets are skipped.
+func (c *copier) plan(plan *treePlan, src, dst string, info os.FileInfo) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	// Create the destination directory. In archive mode it is kept
+	// writable until its contents are in place.
+	mode := info.Mode()
+	if c.archive {
+		mode |= 0o700
+	}
+	if err := os.Mkdir(dst, mode); err != nil {
+		return err
+	}
+	plan.dirs = append(plan.dirs, copyTask{src: src, dst: dst, info: info})
+
+	for _, entry := range entries {
+		t := copyTask{src: filepath.Join(src, entry.Name()), dst: filepath.Join(dst, entry.Name())}
+		if t.info, err = c.stat(t.src); err != nil {
+			return err
+		}
+
+		switch {
+		case t.info.Mode()&os.ModeSymlink != 0:
+			err = c.copySymlink(t.src, t.dst, t.info)
+		case t.info.Mode()&os.ModeSocket != 0:
+			// Sockets only exist while a process listens on them
+			slog.Warn("Skipped socket", "path", t.src)
+		case isSpecial(t.info):
+			err = c.copySpecial(t.src, t.dst, t.info)
+		case t.info.IsDir():
+			err = c.plan(plan, t.src, t.dst, t.info)
+		default:
+			if first, ok := c.linkedCopy(t.info); ok {
+				plan.links = append(plan.links, copyTask{src: first, dst: t.dst, info: t.info})
+				continue
+			}
+			c.recordLink(t.info, t.dst)
+			plan.files = append(plan.files, t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFiles copies files concurrently. The error returned is always that of
+// the earliest failing file: when a file fails, the files after it are
+// abandoned, but the ones before it are still finished.
+func (c *copier) copyFiles(files []copyTask) error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		next     int
+		failed   = len(files) // index of the earliest failed file
+		firstErr error
+		cancels  = make(map[int]context.CancelFunc)
+	)
+
+	for range min(copyWorkers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				i := next
+				if i >= failed {
+					mu.Unlock()
+					return
+				}
+				next++
+				ctx, cancel := context.WithCancel(c.ctx)
+				cancels[i] = cancel
+				mu.Unlock()
+
+				t := files[i]
+				err := c.writeFile(ctx, t.src, t.dst, t.info)
+				cancel()
+
+				mu.Lock()
+				delete(cancels, i)
+				if err != nil && i < failed {
+					failed, firstErr = i, err
+					for j, cancel := range cancels {
+						if j > i {
+							cancel()
+						}
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+func (c *copier) copySymlink(src, dst string, info os.FileInfo) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+	if err := copyMetadata(src, dst, info); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	c.p.AddFiles(1)
+	return nil
+}
+
+// copySpecial recreates the FIFO or device src at dst.
+func (c *copier) copySpecial(src, dst string, info os.FileInfo) error {
+	if err := makeSpecial(src, dst, info); err != nil {
+		return err
+	}
+	if c.archive {
+		if err := copyMetadata(src, dst, info); err != nil {
+			_ = os.Remove(dst)
+			return err
+		}
+	}
+	c.p.AddFiles(1)
+	return nil
+}
+
+// copyFile copies a single file, linking it to an earlier copy instead if
+// both are hard links to the same file.
+func (c *copier) copyFile(oldPath, newPath string, info os.FileInfo) error {
+	if first, ok := c.linkedCopy(info); ok {
+		if err := os.Link(first, newPath); err != nil {
+			return err
+		}
+		c.p.AddFiles(1)
+		c.p.AddBytes(info.Size())
+		return nil
+	}
+
+	if err := c.writeFile(c.ctx, oldPath, newPath, info); err != nil {
+		return err
+	}
+	c.recordLink(info, newPath)
+	return nil
+}
+
+// linkedCopy returns the copy of the file described by info if it is hard
+// linked and has already been copied.
+func (c *copier) linkedCopy(info os.FileInfo) (string, bool) {
+	if !c.archive {
+		return "", false
+	}
+	id, ok := hardLinkID(info)
+	if !ok {
+		return "", false
+	}
+	first, ok := c.links[id]
+	return first, ok
+}
+
+// recordLink remembers dst as the copy of the file described by info, if
+// it is hard linked.
+func (c *copier) recordLink(info os.FileInfo, dst string) {
+	if id, ok := hardLinkID(info); ok && c.archive {
+		c.links[id] = dst
+	}
+}
+
+// writeFile copies the contents, and in archive mode the metadata, of
+// oldPath to the new file newPath.
+func (c *copier) writeFile(ctx context.Context, oldPath, newPath string, info os.FileInfo) (err error) {
+	src, err := os.Open(oldPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// In archive mode the permissions are applied once the extended
+	// attributes have been written
+	perm := info.Mode()
+	if c.archive {
+		perm = 0o600
+	}
+	dst, err := os.OpenFile(newPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	defer func() {
+		if err != nil { // if there was an error, attempt to clean up
+			if err2 := os.Remove(newPath); err2 != nil {
+				slog.Error("Failed to remove file", "error", err2, "path", newPath)
+			}
+		}
+	}()
+
+	if err = copyContents(ctx, dst, src, info.Size(), c.p); err != nil {
+		return err
+	}
+	if c.archive {
+		if err = copyMetadata(oldPath, newPath, info); err != nil {
+			return err
+		}
+	}
+	c.p.AddFiles(1)
+	return nil
+}
+
+// ctxReader stops reading once its context is cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(b)
+}
+
+// countTree returns the number of files and their combined size below the
+// given paths. It stops early if ctx is cancelled.
+func countTree(ctx context.Context, paths []string) (files, bytes int64) {
+	for _, path := range unique(paths) {
+		_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+			if ctx.Err() != nil {
+				return filepath.SkipAll
+			}
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() {
+				files++
+				bytes += info.Size()
+			}
+			return nil
+		})
+	}
+	return files, bytes
+}
@@ -0,0 +1,106 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(src, "file")
+	if err := os.WriteFile(file, []byte("data"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(file, filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("file", filepath.Join(src, "sym")); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, path := range []string{file, src} {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := newCopier(context.Background(), nil, true).copy(src, dst); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	if target, err := os.Readlink(filepath.Join(dst, "sym")); err != nil || target != "file" {
+		t.Errorf("symlink not kept: %q, %v", target, err)
+	}
+
+	a, err := os.Stat(filepath.Join(dst, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.Stat(filepath.Join(dst, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(a, b) {
+		t.Error("hard link not kept")
+	}
+	if a.Mode().Perm() != 0o640 {
+		t.Errorf("got mode %v, want 0640", a.Mode().Perm())
+	}
+
+	for _, path := range []string{filepath.Join(dst, "file"), dst} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: got mtime %v, want %v", path, info.ModTime(), mtime)
+		}
+	}
+}
+
+func TestCopyFilesReportsEarliestError(t *testing.T) {
+	dir := t.TempDir()
+	var files []copyTask
+	for i := range 32 {
+		src := filepath.Join(dir, fmt.Sprintf("src%02d", i))
+		// Leave a few sources missing so that their copies fail
+		if i != 5 && i != 6 && i != 20 {
+			if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		files = append(files, copyTask{src: src, dst: filepath.Join(dir, fmt.Sprintf("dst%02d", i)), info: fakeInfo{}})
+	}
+
+	for range 20 {
+		err := newCopier(context.Background(), nil, false).copyFiles(files)
+		if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "src05") {
+			t.Fatalf("got error %v, want the one for src05", err)
+		}
+		// Everything before the failure has been copied
+		for i := range 5 {
+			assertExists(t, files[i].dst, true)
+		}
+		for _, f := range files {
+			_ = os.Remove(f.dst)
+		}
+	}
+}
+
+// fakeInfo describes an empty regular file.
+type fakeInfo struct{ os.FileInfo }
+
+func (fakeInfo) Mode() os.FileMode { return 0o644 }
+func (fakeInfo) Size() int64       { return 0 }
+func (fakeInfo) Sys() any          { return nil }
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package filesys
+
+import "os"
+
+// deviceID returns the ID of the device the file described by info lives on.
+func deviceID(os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// hardLinkID identifies the file described by info if it has more than
+// one link.
+func hardLinkID(os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// fileOwner returns the user ID owning the file described by info.
+func fileOwner(os.FileInfo) (int, bool) {
+	return 0, false
+}
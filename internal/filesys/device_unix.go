@@ -0,0 +1,36 @@
+//go:build unix
+
+package filesys
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceID returns the ID of the device the file described by info lives on.
+func deviceID(info os.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// hardLinkID identifies the file described by info if it has more than
+// one link.
+func hardLinkID(info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink < 2 {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
+
+// fileOwner returns the user ID owning the file described by info.
+func fileOwner(info os.FileInfo) (int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Uid), true
+}
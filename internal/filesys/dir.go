@@ -49,9 +49,27 @@ func NewDir(path string) (Dir, error) {
 }
 
 func (d Dir) RealSize(ctx context.Context) (int64, error) {
+	return treeSize(ctx, d.path)
+}
+
+// PathsSize returns the combined size of the given paths, including
+// everything below directories.
+func PathsSize(ctx context.Context, paths []string) (int64, error) {
+	var total int64
+	for _, path := range unique(paths) {
+		size, err := treeSize(ctx, path)
+		if err != nil {
+			return total, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+func treeSize(ctx context.Context, path string) (int64, error) {
 	now := time.Now()
 	var size int64
-	err := filepath.Walk(d.path, func(_ string, info os.FileInfo, err error) error {
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -60,7 +78,7 @@ func (d Dir) RealSize(ctx context.Context) (int64, error) {
 		if err != nil {
 			slog.Warn("Error walking directory, ignoring",
 				"error", err,
-				"path", d.path)
+				"path", path)
 			return nil
 		}
 		if !info.IsDir() {
@@ -70,7 +88,7 @@ func (d Dir) RealSize(ctx context.Context) (int64, error) {
 	})
 	slog.Debug("Walk finished",
 		"duration", time.Since(now),
-		"path", d.path,
+		"path", path,
 		"err", err,
 	)
 	return size, err
@@ -45,5 +45,5 @@ func (e DirEntry) ResolveSymlink() (DirEntry, error) {
 }
 
 func (e DirEntry) IsHidden() bool {
-	return len(e.Name()) > 0 && e.Name()[0] == '.'
+	return isHiddenName(e.Name())
 }
@@ -0,0 +1,159 @@
+package filesys
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// finderBatchSize is the most paths a FinderMsg carries.
+	finderBatchSize = 512
+	// finderFlushInterval is how long found paths are held back to be
+	// sent in one batch.
+	finderFlushInterval = 100 * time.Millisecond
+)
+
+// walkTree calls fn for every entry below root, breadth first, so that
+// shallow entries come before deep ones. Hidden entries are skipped unless
+// showHidden is set, as are .git directories and anything ignored by a
+// .gitignore file. Directories that cannot be read are skipped as well.
+func walkTree(ctx context.Context, root string, showHidden bool, fn func(path string, d fs.DirEntry) error) error {
+	type dir struct {
+		path    string
+		ignores []ignoreFile
+	}
+
+	queue := []dir{{path: root, ignores: parentIgnoreFiles(root)}}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		d := queue[0]
+		queue = queue[1:]
+
+		if f, ok := readIgnoreFile(d.path); ok {
+			d.ignores = append(slices.Clip(d.ignores), f)
+		}
+		entries, err := os.ReadDir(d.path)
+		if err != nil {
+			slog.Warn("Error walking directory, ignoring", "error", err, "path", d.path)
+			continue
+		}
+
+		for _, e := range entries {
+			if e.IsDir() && e.Name() == ".git" ||
+				!showHidden && isHiddenName(e.Name()) {
+				continue
+			}
+			path := filepath.Join(d.path, e.Name())
+			if ignored(d.ignores, path, e.IsDir()) {
+				continue
+			}
+			if err := fn(path, e); err != nil {
+				return err
+			}
+			if e.IsDir() {
+				queue = append(queue, dir{path: path, ignores: d.ignores})
+			}
+		}
+	}
+	return nil
+}
+
+func isHiddenName(name string) bool {
+	return len(name) > 0 && name[0] == '.'
+}
+
+// FinderMsg carries a batch of paths found by a Finder.
+type FinderMsg struct {
+	ID int
+	// Paths are relative to the root of the search. Directories end in a
+	// slash.
+	Paths []string
+	// Done is set on the last batch.
+	Done bool
+}
+
+// Finder lists the tree below a directory in the background.
+type Finder struct {
+	id      int
+	results chan FinderMsg
+	cancel  context.CancelFunc
+}
+
+// StartFinder starts walking the tree below root, see walkTree for what is
+// skipped. Found paths are read with Next.
+func StartFinder(id int, root string, showHidden bool) *Finder {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Finder{
+		id:      id,
+		results: make(chan FinderMsg),
+		cancel:  cancel,
+	}
+	go f.run(ctx, root, showHidden)
+	return f
+}
+
+// Next waits for the next batch of paths. It must be called again after
+// each FinderMsg until one has Done set.
+func (f *Finder) Next() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-f.results
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// Stop aborts the walk.
+func (f *Finder) Stop() {
+	f.cancel()
+}
+
+func (f *Finder) run(ctx context.Context, root string, showHidden bool) {
+	defer close(f.results)
+
+	var batch []string
+	lastFlush := time.Now()
+	send := func(done bool) bool {
+		select {
+		case f.results <- FinderMsg{ID: f.id, Paths: batch, Done: done}:
+			batch, lastFlush = nil, time.Now()
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	err := walkTree(ctx, root, showHidden, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel += string(filepath.Separator)
+		}
+		batch = append(batch, rel)
+		if len(batch) >= finderBatchSize || time.Since(lastFlush) >= finderFlushInterval {
+			if !send(false) {
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		slog.Error("Finder failed", "error", err, "root", root)
+	}
+	send(true)
+}
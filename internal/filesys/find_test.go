@@ -0,0 +1,102 @@
+package filesys
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	rules := parseIgnore([]byte(`# build output
+*.log
+!keep.log
+/bin
+build/
+docs/**/*.tmp
+\#notes
+`))
+	files := []ignoreFile{{dir: "/repo", rules: rules}}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/repo/app.log", false, true},
+		{"/repo/sub/deep/app.log", false, true},
+		{"/repo/keep.log", false, false},
+		{"/repo/bin", true, true},
+		{"/repo/sub/bin", true, false},
+		{"/repo/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/sub/build", true, true},
+		{"/repo/docs/a.tmp", false, true},
+		{"/repo/docs/x/y/a.tmp", false, true},
+		{"/repo/a.tmp", false, false},
+		{"/repo/#notes", false, true},
+		{"/other/app.log", false, false},
+	}
+	for _, tt := range tests {
+		if got := ignored(files, tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestFinder(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".git", "src/pkg", "node_modules/dep", ".config"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		".gitignore":         "node_modules/\n*.o\n",
+		"src/.gitignore":     "!keep.o\n",
+		"src/main.go":        "",
+		"src/main.o":         "",
+		"src/keep.o":         "",
+		"src/pkg/util.go":    "",
+		"node_modules/dep/x": "",
+		".config/settings":   "",
+		".git/HEAD":          "",
+		"README":             "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	find := func(showHidden bool) []string {
+		f := StartFinder(1, root, showHidden)
+		defer f.Stop()
+
+		var paths []string
+		for {
+			msg := f.Next()().(FinderMsg)
+			paths = append(paths, msg.Paths...)
+			if msg.Done {
+				break
+			}
+		}
+		slices.Sort(paths)
+		return paths
+	}
+
+	sep := string(filepath.Separator)
+	want := []string{"README", "src" + sep, filepath.Join("src", "keep.o"), filepath.Join("src", "main.go"),
+		filepath.Join("src", "pkg") + sep, filepath.Join("src", "pkg", "util.go")}
+	slices.Sort(want)
+	if got := find(false); !slices.Equal(got, want) {
+		t.Errorf("find(hidden=false) = %q, want %q", got, want)
+	}
+
+	want = append(want, ".config"+sep, filepath.Join(".config", "settings"), ".gitignore",
+		filepath.Join("src", ".gitignore"))
+	slices.Sort(want)
+	if got := find(true); !slices.Equal(got, want) {
+		t.Errorf("find(hidden=true) = %q, want %q", got, want)
+	}
+}
@@ -1,19 +1,26 @@
 package filesys
 
 import (
-	"io"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"maps"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 )
 
 // Selection tracks selected file paths for operations and implements the
 // filelist.SelChecker interface.
 type Selection struct {
 	files map[string]struct{}
+	// onChange is called after every change, used to share the selection
+	onChange func()
 }
 
 func NewSelection() *Selection {
@@ -27,10 +34,12 @@ func (s *Selection) Select(path string) {
 		s.files = make(map[string]struct{})
 	}
 	s.files[path] = struct{}{}
+	s.changed()
 }
 
 func (s *Selection) Deselect(path string) {
 	delete(s.files, path)
+	s.changed()
 }
 
 func (s *Selection) Toggle(path string) bool {
@@ -42,8 +51,43 @@ func (s *Selection) Toggle(path string) bool {
 	return true
 }
 
+// SelectPaths adds paths to the selection.
+func (s *Selection) SelectPaths(paths []string) {
+	if s.files == nil {
+		s.files = make(map[string]struct{})
+	}
+	for _, path := range paths {
+		s.files[path] = struct{}{}
+	}
+	s.changed()
+}
+
+// DeselectPaths removes paths from the selection.
+func (s *Selection) DeselectPaths(paths []string) {
+	for _, path := range paths {
+		delete(s.files, path)
+	}
+	s.changed()
+}
+
+// Invert toggles each of paths. Selected paths not among them are kept.
+func (s *Selection) Invert(paths []string) {
+	if s.files == nil {
+		s.files = make(map[string]struct{})
+	}
+	for _, path := range unique(paths) {
+		if _, ok := s.files[path]; ok {
+			delete(s.files, path)
+		} else {
+			s.files[path] = struct{}{}
+		}
+	}
+	s.changed()
+}
+
 func (s *Selection) Clear() {
 	clear(s.files)
+	s.changed()
 }
 
 func (s *Selection) IsSelected(path string) bool {
@@ -55,116 +99,454 @@ func (s *Selection) Paths() []string {
 	return slices.Collect(maps.Keys(s.files))
 }
 
+// replace sets the selected paths without notifying onChange.
+func (s *Selection) replace(paths []string) {
+	s.files = make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		s.files[path] = struct{}{}
+	}
+}
+
+func (s *Selection) changed() {
+	if s.onChange != nil {
+		s.onChange()
+	}
+}
+
 func (s *Selection) Count() int {
 	return len(s.files)
 }
 
-func DeletePaths(paths []string) error {
+// Resolution decides what happens when a pasted entry collides with an
+// existing one.
+type Resolution int
+
+const (
+	// Unresolved makes the operation fail on a collision.
+	Unresolved Resolution = iota
+	// Overwrite replaces the existing entry.
+	Overwrite
+	// Skip leaves the existing entry alone and skips the source.
+	Skip
+	// KeepBoth pastes the source under a free name with a numeric suffix.
+	KeepBoth
+)
+
+// PasteOptions configure how paths are moved or copied.
+type PasteOptions struct {
+	// Resolutions maps source paths to how a collision with their target is
+	// resolved.
+	Resolutions map[string]Resolution
+	// TrashReplaced moves overwritten entries to the trash instead of
+	// deleting them permanently.
+	TrashReplaced bool
+	// Archive preserves symlinks, hard links and metadata when copying.
+	Archive bool
+	// Verify compares the checksums of copied files with their sources.
+	Verify HashAlgo
+}
+
+// Conflict is a source whose target already exists.
+type Conflict struct {
+	Src string
+	Dst string
+}
+
+// MoveConflicts returns the collisions moving paths into dst would cause.
+func MoveConflicts(paths []string, dst string) []Conflict {
+	return conflicts(paths, func(path string) string {
+		return filepath.Join(dst, filepath.Base(path))
+	})
+}
+
+// CopyConflicts returns the collisions copying paths into dst would cause.
+func CopyConflicts(paths []string, dst string) []Conflict {
+	return conflicts(paths, func(path string) string {
+		return copyTarget(path, dst)
+	})
+}
+
+func conflicts(paths []string, target func(string) string) []Conflict {
+	var out []Conflict
 	for _, path := range unique(paths) {
-		if err := os.RemoveAll(path); err != nil {
-			return err
+		dst := target(path)
+		if dst == filepath.Clean(path) {
+			continue
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			out = append(out, Conflict{Src: path, Dst: dst})
 		}
-		slog.Info("Deleted", "path", path)
 	}
-	return nil
+	return out
 }
 
-func MovePaths(paths []string, dst string) error {
-	for _, path := range unique(paths) {
-		if err := os.Rename(path, filepath.Join(dst, filepath.Base(path))); err != nil {
-			return err
+// resolve returns the path to paste src to, applying its resolution if
+// target already exists. It returns ok=false if src should be skipped. When
+// target is overwritten, the path returned is a temporary name next to it,
+// and the replacement must be committed once the new entry is complete.
+func (o PasteOptions) resolve(src, target string) (_ string, r *replacement, ok bool, err error) {
+	if _, err := os.Lstat(target); errors.Is(err, os.ErrNotExist) {
+		return target, nil, true, nil
+	} else if err != nil {
+		return "", nil, false, err
+	}
+
+	switch o.Resolutions[src] {
+	case Skip:
+		slog.Info("Skipped", "path", src, "target", target)
+		return "", nil, false, nil
+	case KeepBoth:
+		return freeName(target, ""), nil, true, nil
+	case Overwrite:
+		if err := checkOverwrite(src, target); err != nil {
+			return "", nil, false, err
 		}
-		slog.Info("Moved", "path", path, "dst", dst)
+		r := &replacement{target: target, tmp: tempSibling(target, "new"), trash: o.TrashReplaced}
+		return r.tmp, r, true, nil
+	}
+	return "", nil, false, fmt.Errorf("%q already exists", target)
+}
+
+// checkOverwrite refuses to overwrite src itself or a directory containing
+// it, since replacing the target would destroy the source.
+func checkOverwrite(src, target string) error {
+	realSrc, err := realPath(src)
+	if err != nil {
+		return err
+	}
+	realTarget, err := realPath(target)
+	if err != nil {
+		return err
+	}
+	if realSrc == realTarget || strings.HasPrefix(realSrc, realTarget+string(filepath.Separator)) {
+		return fmt.Errorf("cannot overwrite %q with %q, which it contains", target, src)
+	}
+
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	targetInfo, err := os.Lstat(target)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(srcInfo, targetInfo) {
+		return fmt.Errorf("cannot overwrite %q with itself", target)
 	}
 	return nil
 }
 
-func CopyPaths(paths []string, dst string) error {
-	for _, path := range unique(paths) {
-		if err := CopyAll(path, dst); err != nil {
-			return err
+// realPath returns the absolute path of path with the symlinks among its
+// parents resolved. The entry itself is not followed.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+// tempSibling returns a free hidden name next to path, used to stage
+// changes to it.
+func tempSibling(path, tag string) string {
+	return freeName(filepath.Join(filepath.Dir(path), ".sail-"+tag+"-"+filepath.Base(path)), "")
+}
+
+// replacement is an existing entry that a paste overwrites. The new entry
+// is written to tmp first, so that the existing one is only touched once
+// the paste has succeeded.
+type replacement struct {
+	target string
+	tmp    string
+	trash  bool
+}
+
+// commit moves the new entry at dst over the target, and then deletes the
+// old entry or moves it to the trash. It returns where the new entry ended
+// up, along with the ops that restore the old one. A nil replacement
+// leaves dst alone. If commit fails, the target is untouched and the new
+// entry is still at dst.
+func (r *replacement) commit(dst string) (string, []Op, error) {
+	if r == nil {
+		return dst, nil, nil
+	}
+
+	old := tempSibling(r.target, "old")
+	if err := os.Rename(r.target, old); err != nil {
+		return "", nil, err
+	}
+	if err := os.Rename(dst, r.target); err != nil {
+		if err2 := os.Rename(old, r.target); err2 != nil {
+			slog.Error("Failed to put back replaced entry", "error", err2, "path", old, "target", r.target)
 		}
+		return "", nil, err
 	}
-	return nil
+
+	// The new entry is in place, so failing to get rid of the old one is
+	// only logged
+	if r.trash {
+		trashed, err := trashPathAs(old, r.target)
+		if err != nil {
+			slog.Error("Failed to trash replaced entry", "error", err, "path", old)
+			return r.target, nil, nil
+		}
+		return r.target, []Op{{Kind: OpTrash, Src: r.target, Dst: trashed}}, nil
+	}
+	if err := os.RemoveAll(old); err != nil {
+		slog.Error("Failed to delete replaced entry", "error", err, "path", old)
+	}
+	slog.Info("Deleted", "path", r.target)
+	return r.target, nil, nil
 }
 
-// CopyAll copies all files in the given path to the new directory.
-func CopyAll(src, dst string) (err error) {
-	slog.Info("Copy", "src", src, "dst", dst)
+// freeName returns path if it does not exist. Otherwise suffix is added
+// before the extension, followed by a counter if that is taken as well.
+// Without a suffix the counter starts at 1, so the first free name for
+// "a.txt" is "a_1.txt".
+func freeName(path, suffix string) string {
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return path
+	}
 
-	stat, err := os.Stat(src)
-	if err != nil {
-		return err
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := stem + suffix + "_" + strconv.Itoa(i) + ext
+		if i == 1 && suffix != "" {
+			candidate = stem + suffix + ext
+		}
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
 	}
+}
 
-	if !stat.IsDir() {
-		return copyFile(src, filepath.Join(dst, filepath.Base(src)))
+// MovePaths moves paths into dst. Cancelling ctx stops before the next
+// entry, or aborts a cross-device copy of the current one.
+func MovePaths(ctx context.Context, paths []string, dst string, opts PasteOptions, p *Progress) ([]Op, error) {
+	ops := make([]Op, 0, len(paths))
+	for _, path := range unique(paths) {
+		if err := ctx.Err(); err != nil {
+			return ops, err
+		}
+		target := filepath.Join(dst, filepath.Base(path))
+		if target == filepath.Clean(path) {
+			p.Handled(path)
+			continue
+		}
+
+		target, r, ok, err := opts.resolve(path, target)
+		if err != nil {
+			return ops, err
+		}
+		if !ok {
+			p.Handled(path)
+			continue
+		}
+
+		copied, err := renameOrCopy(ctx, path, target, p)
+		if err != nil {
+			return ops, err
+		}
+		placed, replaced, err := r.commit(target)
+		if err != nil {
+			if copied {
+				// The source is still there, so only the copy has to go
+				if err2 := os.RemoveAll(target); err2 != nil {
+					slog.Error("Failed to remove copy", "error", err2, "path", target)
+				}
+			} else if err2 := os.Rename(target, path); err2 != nil {
+				slog.Error("Failed to move back", "error", err2, "path", target, "dst", path)
+			}
+			return ops, err
+		}
+		slog.Info("Moved", "path", path, "dst", dst)
+		ops = append(ops, replaced...)
+		ops = append(ops, Op{Kind: OpMove, Src: path, Dst: placed})
+		p.Handled(path)
+		if copied {
+			if err := os.RemoveAll(path); err != nil {
+				return ops, err
+			}
+		} else {
+			p.AddFiles(1)
+		}
 	}
+	return ops, nil
+}
 
-	entries, err := os.ReadDir(src)
-	if err != nil {
+// movePath renames from to to. If they live on different filesystems, from
+// is copied instead and only removed once the copy has been verified.
+func movePath(ctx context.Context, from, to string) error {
+	copied, err := renameOrCopy(ctx, from, to, nil)
+	if err != nil || !copied {
 		return err
 	}
+	return os.RemoveAll(from)
+}
 
-	// Create the destination directory
-	dst = filepath.Join(dst, filepath.Base(src))
-	if filepath.Clean(dst) == filepath.Clean(src) {
-		dst += "_copy"
+// renameOrCopy renames from to to, or copies it if they live on different
+// filesystems, in which case copied is set and from is left for the caller
+// to remove. The files and bytes copied are added to p.
+func renameOrCopy(ctx context.Context, from, to string, p *Progress) (copied bool, err error) {
+	err = os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return false, err
 	}
 
-	if err = os.Mkdir(dst, stat.Mode()); err != nil {
-		return err
+	slog.Info("Cross-device move, copying instead", "path", from, "dst", to)
+	if err := newCopier(ctx, p, true).copy(from, to); err != nil {
+		return false, err
+	}
+	if err := verifyCopy(from, to); err != nil {
+		if err2 := os.RemoveAll(to); err2 != nil {
+			slog.Error("Failed to remove unverified copy", "error", err2, "path", to)
+		}
+		return false, err
 	}
+	return true, nil
+}
 
-	defer func() {
-		if err != nil { // if there was an error, attempt to clean up
-			if err2 := os.RemoveAll(dst); err2 != nil {
-				slog.Error("Failed to remove directory", "error", err2, "path", dst)
+// countMove returns the amount of work moving paths into dst takes. Entries
+// that can be renamed count as a single file, the ones that have to be
+// copied to another filesystem count like a copy.
+func countMove(ctx context.Context, paths []string, dst string) (files, bytes int64) {
+	dstDev, dstOK := uint64(0), false
+	if info, err := os.Stat(dst); err == nil {
+		dstDev, dstOK = deviceID(info)
+	}
+	for _, path := range unique(paths) {
+		if info, err := os.Lstat(path); err == nil && dstOK {
+			if dev, ok := deviceID(info); ok && dev == dstDev {
+				files++
+				continue
 			}
 		}
-	}()
+		f, b := countTree(ctx, []string{path})
+		files, bytes = files+f, bytes+b
+	}
+	return files, bytes
+}
 
-	for _, entry := range entries {
-		if err = CopyAll(filepath.Join(src, entry.Name()), dst); err != nil {
+// verifyCopy checks that dst mirrors the tree at src: every entry but
+// sockets exists with the same type, and regular files have the same size.
+func verifyCopy(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
 			return err
 		}
-	}
+		if d.Type()&fs.ModeSocket != 0 {
+			return nil // not copied
+		}
 
-	return nil
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		srcInfo, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		dstInfo, err := os.Lstat(filepath.Join(dst, rel))
+		if err != nil {
+			return fmt.Errorf("copy verification failed: %w", err)
+		}
+
+		if srcInfo.Mode().Type() != dstInfo.Mode().Type() ||
+			srcInfo.Mode().IsRegular() && srcInfo.Size() != dstInfo.Size() {
+			return fmt.Errorf("copy verification failed: %q differs from %q", filepath.Join(dst, rel), path)
+		}
+		return nil
+	})
 }
 
-func copyFile(oldPath, newPath string) (err error) {
-	if filepath.Clean(oldPath) == filepath.Clean(newPath) {
-		newFileName := strings.TrimRight(filepath.Base(newPath), filepath.Ext(newPath)) + "_copy"
-		newPath = filepath.Join(filepath.Dir(newPath), newFileName+filepath.Ext(newPath))
+// RenamePath renames the entry at path to newName within the same directory
+// and returns the new path. It refuses to replace an existing entry.
+func RenamePath(path, newName string) (string, error) {
+	if newName == "" || newName == "." || newName == ".." || strings.ContainsRune(newName, filepath.Separator) {
+		return "", fmt.Errorf("invalid name %q", newName)
 	}
 
-	src, err := os.Open(oldPath)
-	if err != nil {
-		return err
+	newPath := filepath.Join(filepath.Dir(path), newName)
+	if newPath == path {
+		return newPath, nil
+	}
+
+	if info, err := os.Lstat(newPath); err == nil {
+		// Allow case-only renames on case-insensitive filesystems
+		oldInfo, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		if !os.SameFile(info, oldInfo) {
+			return "", fmt.Errorf("%q already exists", newName)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
 	}
-	defer src.Close()
 
-	info, err := src.Stat()
+	if err := os.Rename(path, newPath); err != nil {
+		return "", err
+	}
+	slog.Info("Renamed", "path", path, "newPath", newPath)
+	return newPath, nil
+}
+
+// CreateFile creates an empty file at path, creating missing parent
+// directories like `mkdir -p`. It returns every path it created.
+func CreateFile(path string) ([]string, error) {
+	created, err := mkdirAll(filepath.Dir(path))
 	if err != nil {
-		return err
+		return created, err
 	}
 
-	dst, err := os.OpenFile(newPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, info.Mode())
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
 	if err != nil {
-		return err
+		return created, err
 	}
-	defer dst.Close()
-	defer func() {
-		if err != nil { // if there was an error, attempt to clean up
-			if err2 := os.Remove(newPath); err2 != nil {
-				slog.Error("Failed to remove file", "error", err2, "path", newPath)
-			}
+	slog.Info("Created file", "path", path)
+	return append(created, path), f.Close()
+}
+
+// Mkdir creates the directory at path along with any missing parents, like
+// `mkdir -p`, but fails if path itself already exists. It returns every path
+// it created.
+func Mkdir(path string) ([]string, error) {
+	if _, err := os.Lstat(path); err == nil {
+		return nil, fmt.Errorf("%q already exists", path)
+	}
+	return mkdirAll(path)
+}
+
+// mkdirAll creates path and all missing parents, returning the created
+// directories from the outermost to the innermost.
+func mkdirAll(path string) ([]string, error) {
+	var missing []string
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
 		}
-	}()
+		missing = append(missing, p)
+		if p == filepath.Dir(p) {
+			break
+		}
+	}
 
-	_, err = io.Copy(dst, src)
-	return err
+	created := make([]string, 0, len(missing))
+	for _, p := range slices.Backward(missing) {
+		if err := os.Mkdir(p, 0o777); err != nil {
+			return created, err
+		}
+		slog.Info("Created directory", "path", p)
+		created = append(created, p)
+	}
+	return created, nil
 }
 
 func unique(paths []string) []string {
@@ -0,0 +1,251 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestRenamePathRefusesExisting(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("create %q: %v", name, err)
+		}
+	}
+
+	if _, err := RenamePath(filepath.Join(dir, "a"), "b"); err == nil {
+		t.Fatal("expected rename onto existing entry to fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b")); string(data) != "b" {
+		t.Fatalf("existing entry was clobbered, got %q", data)
+	}
+
+	newPath, err := RenamePath(filepath.Join(dir, "a"), "c")
+	if err != nil {
+		t.Fatalf("RenamePath failed: %v", err)
+	}
+	if newPath != filepath.Join(dir, "c") {
+		t.Fatalf("unexpected new path %q", newPath)
+	}
+	if _, err := os.Stat(newPath); err != nil {
+		t.Fatalf("renamed entry missing: %v", err)
+	}
+
+	for _, name := range []string{"", ".", "..", "x/y"} {
+		if _, err := RenamePath(newPath, name); err == nil {
+			t.Errorf("expected invalid name %q to be rejected", name)
+		}
+	}
+}
+
+func TestCreateNested(t *testing.T) {
+	dir := t.TempDir()
+
+	created, err := CreateFile(filepath.Join(dir, "a", "b", "file"))
+	if err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a"), filepath.Join(dir, "a", "b"), filepath.Join(dir, "a", "b", "file")}
+	if !slices.Equal(created, want) {
+		t.Fatalf("expected created %v, got %v", want, created)
+	}
+
+	if _, err := CreateFile(filepath.Join(dir, "a", "b", "file")); err == nil {
+		t.Fatal("expected creating an existing file to fail")
+	}
+
+	created, err = Mkdir(filepath.Join(dir, "a", "c", "d") + "/")
+	if err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected 2 created directories, got %v", created)
+	}
+	if _, err := Mkdir(filepath.Join(dir, "a", "c")); err == nil {
+		t.Fatal("expected creating an existing directory to fail")
+	}
+}
+
+func TestPasteResolutions(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, name := range []string{"over", "skip", "both", "none"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte("src"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, name), []byte("dst"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths := []string{filepath.Join(src, "over"), filepath.Join(src, "skip"), filepath.Join(src, "both")}
+	if got := CopyConflicts(paths, dst); len(got) != len(paths) {
+		t.Fatalf("expected %d conflicts, got %v", len(paths), got)
+	}
+
+	opts := PasteOptions{Resolutions: map[string]Resolution{
+		paths[0]: Overwrite,
+		paths[1]: Skip,
+		paths[2]: KeepBoth,
+	}}
+	if _, err := CopyPaths(context.Background(), paths, dst, opts, nil); err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+
+	want := map[string]string{"over": "src", "skip": "dst", "both": "dst", "both_1": "src"}
+	for name, content := range want {
+		if data, _ := os.ReadFile(filepath.Join(dst, name)); string(data) != content {
+			t.Errorf("%q: expected %q, got %q", name, content, data)
+		}
+	}
+
+	// Unresolved conflicts must fail instead of clobbering
+	if _, err := MovePaths(context.Background(), []string{filepath.Join(src, "none")}, dst, PasteOptions{}, nil); err == nil {
+		t.Fatal("expected unresolved conflict to fail")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "none")); string(data) != "dst" {
+		t.Fatalf("existing file was clobbered: %q", data)
+	}
+}
+
+func TestOverwriteKeepsTargetUntilDone(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	src := filepath.Join(dir, "src", "tree")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{src, filepath.Join(dst, "tree")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, "large"), make([]byte, 64<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(dst, "tree", "old")
+	if err := os.WriteFile(old, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cancelled copy leaves the target as it was
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var p Progress
+	go func() {
+		for ctx.Err() == nil && p.Stat().BytesDone == 0 {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+	opts := PasteOptions{Resolutions: map[string]Resolution{src: Overwrite}, TrashReplaced: true}
+	if _, err := CopyPaths(ctx, []string{src}, dst, opts, &p); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	assertExists(t, old, true)
+	assertEntries(t, dst, "tree")
+
+	// A finished copy replaces it and trashes the old entry
+	ops, err := CopyPaths(context.Background(), []string{src}, dst, opts, nil)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	assertExists(t, old, false)
+	assertExists(t, filepath.Join(dst, "tree", "large"), true)
+	assertEntries(t, dst, "tree")
+	if len(ops) != 2 || ops[0].Kind != OpTrash || ops[0].Src != filepath.Join(dst, "tree") {
+		t.Fatalf("got ops %+v, want the trashed target followed by the copy", ops)
+	}
+	if _, err := os.Stat(filepath.Join(ops[0].Dst, "old")); err != nil {
+		t.Fatalf("old entry is not in the trash: %v", err)
+	}
+}
+
+func TestOverwriteRefusesSourceParent(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a", "a")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := PasteOptions{Resolutions: map[string]Resolution{src: Overwrite}}
+	if _, err := CopyPaths(context.Background(), []string{src}, dir, opts, nil); err == nil {
+		t.Fatal("expected copying over the parent of the source to fail")
+	}
+	if _, err := MovePaths(context.Background(), []string{src}, dir, opts, nil); err == nil {
+		t.Fatal("expected moving over the parent of the source to fail")
+	}
+	assertExists(t, filepath.Join(src, "file"), true)
+	assertEntries(t, dir, "a")
+}
+
+// assertEntries checks that dir holds exactly the given names, so that no
+// temporary entries are left behind.
+func assertEntries(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if !slices.Equal(got, names) {
+		t.Fatalf("%q holds %q, want %q", dir, got, names)
+	}
+}
+
+func TestMoveAcrossDevices(t *testing.T) {
+	other, err := os.MkdirTemp("/dev/shm", "sail-test-")
+	if err != nil {
+		t.Skipf("no second filesystem available: %v", err)
+	}
+	defer os.RemoveAll(other)
+
+	dir := t.TempDir()
+	otherInfo, err := os.Stat(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherDev, _ := deviceID(otherInfo)
+	dirDev, _ := deviceID(dirInfo)
+	if otherDev == dirDev {
+		t.Skip("temporary directories share a filesystem")
+	}
+
+	src := filepath.Join(dir, "tree")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, bytes := countMove(context.Background(), []string{src}, other)
+	if files != 1 || bytes != 4 {
+		t.Errorf("countMove = %d files, %d bytes, want 1 and 4", files, bytes)
+	}
+
+	p := &Progress{}
+	if _, err := MovePaths(context.Background(), []string{src}, other, PasteOptions{}, p); err != nil {
+		t.Fatalf("MovePaths failed: %v", err)
+	}
+	if stat := p.Stat(); stat.FilesDone != 1 || stat.BytesDone != 4 {
+		t.Errorf("got progress %+v, want 1 file and 4 bytes", stat)
+	}
+
+	assertExists(t, src, false)
+	if data, _ := os.ReadFile(filepath.Join(other, "tree", "sub", "file")); string(data) != "data" {
+		t.Fatalf("moved content mismatch: %q", data)
+	}
+}
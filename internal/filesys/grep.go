@@ -0,0 +1,296 @@
+package filesys
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alx99/sail/internal/util"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// grepWorkers is the number of files searched concurrently.
+	grepWorkers = 8
+	// grepMaxLine is the longest line searched. Files with longer lines
+	// are only searched up to that line.
+	grepMaxLine = 1 << 20
+	// grepMaxMatches is the most matches a search reports.
+	grepMaxMatches = 10000
+	// grepSnippetLen is the most bytes of a matching line that are kept.
+	grepSnippetLen = 200
+)
+
+// GrepMatch is a line of a file matching a content search.
+type GrepMatch struct {
+	Path string
+	// Line is the 1-based line number.
+	Line int
+	// Text is the line, cut around the match if it is long.
+	Text string
+	// Start and End are the byte offsets of the match in Text.
+	Start, End int
+}
+
+// GrepMsg carries a batch of matches found by a Grep.
+type GrepMsg struct {
+	ID      int
+	Matches []GrepMatch
+	// Done is set on the last batch.
+	Done bool
+	// Truncated is set if the search stopped at grepMaxMatches.
+	Truncated bool
+}
+
+// Grep searches the contents of the files below a directory in the
+// background.
+type Grep struct {
+	id      int
+	results chan GrepMsg
+	cancel  context.CancelFunc
+}
+
+// StartGrep starts searching the regular files below root for pattern. A
+// pattern enclosed in slashes, like /func \w+/, is a regular expression,
+// anything else is matched literally. Binary files are skipped, and so is
+// whatever walkTree skips. Matches are read with Next.
+func StartGrep(id int, root, pattern string, showHidden bool) (*Grep, error) {
+	match, err := compileGrepPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &Grep{
+		id:      id,
+		results: make(chan GrepMsg),
+		cancel:  cancel,
+	}
+	go g.run(ctx, root, showHidden, match)
+	return g, nil
+}
+
+// Next waits for the next batch of matches. It must be called again after
+// each GrepMsg until one has Done set.
+func (g *Grep) Next() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-g.results
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// Stop aborts the search.
+func (g *Grep) Stop() {
+	g.cancel()
+}
+
+// matchFunc returns the byte offsets of the first match in line.
+type matchFunc func(line string) (start, end int, ok bool)
+
+func compileGrepPattern(pattern string) (matchFunc, error) {
+	if pattern == "" {
+		return nil, errors.New("empty search pattern")
+	}
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return func(line string) (int, int, bool) {
+			loc := re.FindStringIndex(line)
+			if loc == nil {
+				return 0, 0, false
+			}
+			return loc[0], loc[1], true
+		}, nil
+	}
+
+	return func(line string) (int, int, bool) {
+		i := strings.Index(line, pattern)
+		return i, i + len(pattern), i >= 0
+	}, nil
+}
+
+// run walks the tree, feeding the files to a pool of workers, and sends
+// their matches in batches.
+func (g *Grep) run(ctx context.Context, root string, showHidden bool, match matchFunc) {
+	defer close(g.results)
+
+	walkCtx, stopWalk := context.WithCancel(ctx)
+	defer stopWalk()
+
+	paths := make(chan string)
+	found := make(chan []GrepMatch)
+	var wg sync.WaitGroup
+	for range grepWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				matches, err := grepFile(walkCtx, path, match)
+				if err != nil && walkCtx.Err() == nil {
+					slog.Warn("Error searching file, ignoring", "error", err, "path", path)
+				}
+				if len(matches) == 0 {
+					continue
+				}
+				select {
+				case found <- matches:
+				case <-walkCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		err := walkTree(walkCtx, root, showHidden, func(path string, d fs.DirEntry) error {
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			select {
+			case paths <- path:
+				return nil
+			case <-walkCtx.Done():
+				return walkCtx.Err()
+			}
+		})
+		if err != nil && walkCtx.Err() == nil {
+			slog.Error("Search failed", "error", err, "root", root)
+		}
+		close(paths)
+		wg.Wait()
+		close(found)
+	}()
+
+	var batch []GrepMatch
+	total := 0
+	send := func(done, truncated bool) bool {
+		select {
+		case g.results <- GrepMsg{ID: g.id, Matches: batch, Done: done, Truncated: truncated}:
+			batch = nil
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	t := time.NewTicker(finderFlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case matches, ok := <-found:
+			if !ok {
+				if ctx.Err() == nil {
+					send(true, false)
+				}
+				return
+			}
+			if total+len(matches) >= grepMaxMatches {
+				batch = append(batch, matches[:grepMaxMatches-total]...)
+				stopWalk()
+				go func() {
+					for range found {
+					}
+				}()
+				send(true, true)
+				return
+			}
+			batch = append(batch, matches...)
+			total += len(matches)
+			if len(batch) >= finderBatchSize && !send(false, false) {
+				return
+			}
+		case <-t.C:
+			if len(batch) > 0 && !send(false, false) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// grepFile returns the lines of the file at path that match. Binary files
+// have no matches.
+func grepFile(ctx context.Context, path string, match matchFunc) ([]GrepMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(ctxReader{ctx: ctx, r: f})
+	if probe, _ := br.Peek(util.BinaryProbeSize); util.IsBinary(probe) {
+		return nil, nil
+	}
+
+	var matches []GrepMatch
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 0, 64*1024), grepMaxLine)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if start, end, ok := match(text); ok {
+			matches = append(matches, snippet(path, line, text, start, end))
+		}
+	}
+	return matches, sc.Err()
+}
+
+// snippet returns the match of text between start and end, keeping at most
+// grepSnippetLen bytes of the line around it.
+func snippet(path string, line int, text string, start, end int) GrepMatch {
+	// Leading whitespace and tabs only waste room
+	trimmed := strings.TrimLeft(text, " \t")
+	cut := len(text) - len(trimmed)
+	text = strings.ReplaceAll(trimmed, "\t", " ")
+	start, end = max(0, start-cut), max(0, end-cut)
+
+	if len(text) > grepSnippetLen {
+		from := max(0, min(start-grepSnippetLen/4, len(text)-grepSnippetLen))
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		to := min(len(text), from+grepSnippetLen)
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to--
+		}
+		text = text[from:to]
+		start, end = start-from, min(end-from, len(text))
+	}
+	text, start, end = sanitizeSpan(text, start, end)
+	return GrepMatch{Path: path, Line: line, Text: text, Start: start, End: end}
+}
+
+// sanitizeSpan replaces the control characters in text, which may change
+// its length, and moves start and end along with the text they point at.
+func sanitizeSpan(text string, start, end int) (string, int, int) {
+	var sb strings.Builder
+	newStart, newEnd := -1, -1
+	for i, r := range text {
+		if newStart < 0 && i >= start {
+			newStart = sb.Len()
+		}
+		if newEnd < 0 && i >= end {
+			newEnd = sb.Len()
+		}
+		sb.WriteRune(util.SafeRune(r))
+	}
+	if newStart < 0 {
+		newStart = sb.Len()
+	}
+	if newEnd < 0 {
+		newEnd = sb.Len()
+	}
+	return sb.String(), newStart, newEnd
+}
@@ -0,0 +1,92 @@
+package filesys
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.go":       "package a\n\nfunc Hello() {}\n",
+		"sub/b.go":   "package b\n\t// hello world\nfunc hello() {}\n",
+		"binary":     "func Hello\x00\x01",
+		".hidden.go": "func Hello() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	grep := func(pattern string) []string {
+		t.Helper()
+		g, err := StartGrep(1, root, pattern, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer g.Stop()
+
+		var got []string
+		for {
+			msg := g.Next()().(GrepMsg)
+			for _, m := range msg.Matches {
+				rel, _ := filepath.Rel(root, m.Path)
+				rel = filepath.ToSlash(rel)
+				got = append(got, rel+":"+m.Text[:m.Start]+"["+m.Text[m.Start:m.End]+"]"+m.Text[m.End:])
+			}
+			if msg.Done {
+				break
+			}
+		}
+		slices.Sort(got)
+		return got
+	}
+
+	if got, want := grep("Hello"), []string{"a.go:func [Hello]() {}"}; !slices.Equal(got, want) {
+		t.Errorf("grep(Hello) = %q, want %q", got, want)
+	}
+	want := []string{"a.go:func [Hello]() {}", "sub/b.go:// [hello] world", "sub/b.go:func [hello]() {}"}
+	if got := grep("/(?i)hello/"); !slices.Equal(got, want) {
+		t.Errorf("grep(/(?i)hello/) = %q, want %q", got, want)
+	}
+
+	if _, err := StartGrep(1, root, "/(/", false); err == nil {
+		t.Error("StartGrep succeeded with an invalid regular expression")
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	line := strings.Repeat("é", 300) + "needle" + strings.Repeat("x", 300)
+	start := strings.Index(line, "needle")
+	m := snippet("f", 1, line, start, start+len("needle"))
+
+	if len(m.Text) > grepSnippetLen {
+		t.Fatalf("snippet is %d bytes, want at most %d", len(m.Text), grepSnippetLen)
+	}
+	if got := m.Text[m.Start:m.End]; got != "needle" {
+		t.Fatalf("match = %q, want needle", got)
+	}
+	if !strings.HasPrefix(m.Text, "é") {
+		t.Fatalf("snippet %q does not start on a rune boundary", m.Text[:4])
+	}
+}
+
+func TestSnippetSanitizes(t *testing.T) {
+	line := "\x1b[2J\u0085 needle\x07"
+	start := strings.Index(line, "needle")
+	m := snippet("f", 1, line, start, start+len("needle"))
+
+	if m.Text != "?[2J? needle?" {
+		t.Errorf("got snippet %q, want control characters replaced", m.Text)
+	}
+	if got := m.Text[m.Start:m.End]; got != "needle" {
+		t.Errorf("match = %q, want needle", got)
+	}
+}
@@ -0,0 +1,176 @@
+package filesys
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashAlgo selects the hash used to verify and compare files.
+type HashAlgo int
+
+const (
+	// HashNone disables verification.
+	HashNone HashAlgo = iota
+	HashSHA256
+	HashXXH64
+)
+
+// ParseHashAlgo parses the name of a hash as used in the configuration.
+func ParseHashAlgo(name string) (HashAlgo, error) {
+	switch name {
+	case "", "none":
+		return HashNone, nil
+	case "sha256":
+		return HashSHA256, nil
+	case "xxhash":
+		return HashXXH64, nil
+	}
+	return HashNone, fmt.Errorf("unknown hash %q, expected sha256 or xxhash", name)
+}
+
+func (a HashAlgo) String() string {
+	switch a {
+	case HashSHA256:
+		return "sha256"
+	case HashXXH64:
+		return "xxhash"
+	}
+	return "none"
+}
+
+func (a HashAlgo) new() hash.Hash {
+	if a == HashXXH64 {
+		return xxhash.New()
+	}
+	return sha256.New()
+}
+
+// ChecksumError is returned when a copy does not match its source.
+type ChecksumError struct {
+	Src, Dst string
+	Algo     HashAlgo
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum of %q does not match %q", e.Algo, e.Dst, e.Src)
+}
+
+// HashFile returns the digest of the regular file at path. The bytes read
+// are added to p.
+func HashFile(ctx context.Context, algo HashAlgo, path string, p *Progress) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", path)
+	}
+
+	h := algo.new()
+	if _, err := io.Copy(progressWriter{w: h, p: p}, ctxReader{ctx: ctx, r: f}); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// verifyTree checks that every regular file below src has the same digest
+// as its counterpart below dst. The tree is walked with stat, the way the
+// copier that made dst walked it, so files reached through symlinks are
+// checked if the copier followed them. Only the bytes read from dst are
+// added to p.
+func verifyTree(ctx context.Context, algo HashAlgo, stat func(string) (os.FileInfo, error), src, dst string, p *Progress) error {
+	info, err := stat(src)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := verifyTree(ctx, algo, stat, filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	want, err := HashFile(ctx, algo, src, nil)
+	if err != nil {
+		return err
+	}
+	got, err := HashFile(ctx, algo, dst, p)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return ChecksumError{Src: src, Dst: dst, Algo: algo}
+	}
+	return nil
+}
+
+// Comparison is the result of comparing files by their digests.
+type Comparison struct {
+	Algo  HashAlgo
+	Paths []string
+	// Digests holds the hex encoded digest of each path.
+	Digests []string
+}
+
+// Identical reports whether all compared files have the same contents.
+func (c Comparison) Identical() bool {
+	for _, d := range c.Digests {
+		if d != c.Digests[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// Distinct returns the number of different contents among the files.
+func (c Comparison) Distinct() int {
+	seen := make(map[string]struct{}, len(c.Digests))
+	for _, d := range c.Digests {
+		seen[d] = struct{}{}
+	}
+	return len(seen)
+}
+
+// CompareFiles hashes the given files so their contents can be compared.
+// The files and bytes read are added to p.
+func CompareFiles(ctx context.Context, algo HashAlgo, paths []string, p *Progress) (Comparison, error) {
+	if len(paths) < 2 {
+		return Comparison{}, fmt.Errorf("select at least two files to compare")
+	}
+
+	c := Comparison{Algo: algo, Paths: paths, Digests: make([]string, len(paths))}
+	for i, path := range paths {
+		sum, err := HashFile(ctx, algo, path, p)
+		if err != nil {
+			return Comparison{}, err
+		}
+		c.Digests[i] = hex.EncodeToString(sum)
+		p.AddFiles(1)
+	}
+	return c, nil
+}
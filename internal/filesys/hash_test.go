@@ -0,0 +1,108 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifiedCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{filepath.Join(src, "sub"), dst} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, algo := range []HashAlgo{HashSHA256, HashXXH64} {
+		target := filepath.Join(dst, algo.String())
+		if err := os.Mkdir(target, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CopyPaths(context.Background(), []string{src}, target, PasteOptions{Verify: algo}, nil); err != nil {
+			t.Fatalf("%s: verified copy failed: %v", algo, err)
+		}
+
+		// Corrupt the copy and verify again
+		copied := filepath.Join(target, "src")
+		if err := os.WriteFile(filepath.Join(copied, "sub", "file"), []byte("dada"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var sumErr ChecksumError
+		if err := verifyTree(context.Background(), algo, os.Lstat, src, copied, nil); !errors.As(err, &sumErr) {
+			t.Fatalf("%s: got error %v, want a checksum mismatch", algo, err)
+		}
+	}
+}
+
+func TestVerifyFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	orig := filepath.Join(dir, "orig")
+	if err := os.MkdirAll(filepath.Join(orig, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(orig, "sub", "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Both the top-level entry and a directory inside it are symlinks
+	if err := os.Symlink("../orig/sub", filepath.Join(orig, "linked")); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "src")
+	if err := os.Symlink(orig, src); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CopyPaths(context.Background(), []string{src}, dst, PasteOptions{Verify: HashSHA256}, nil); err != nil {
+		t.Fatalf("verified copy failed: %v", err)
+	}
+
+	copied := filepath.Join(dst, "src")
+	if err := os.WriteFile(filepath.Join(copied, "linked", "file"), []byte("dada"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sumErr ChecksumError
+	if err := verifyTree(context.Background(), HashSHA256, os.Stat, src, copied, nil); !errors.As(err, &sumErr) {
+		t.Fatalf("got error %v, want a checksum mismatch below the symlink", err)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	contents := map[string]string{"a": "same", "b": "same", "c": "other"}
+	for name, data := range contents {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	c, err := CompareFiles(context.Background(), HashSHA256, path("a", "b"), nil)
+	if err != nil || !c.Identical() {
+		t.Errorf("a and b: got %+v, %v, want identical", c, err)
+	}
+	c, err = CompareFiles(context.Background(), HashXXH64, path("a", "b", "c"), nil)
+	if err != nil || c.Identical() || c.Distinct() != 2 {
+		t.Errorf("a, b and c: got %+v, %v, want 2 distinct", c, err)
+	}
+	if _, err := CompareFiles(context.Background(), HashSHA256, path("a", "."), nil); err == nil {
+		t.Error("expected comparing a directory to fail")
+	}
+}
@@ -0,0 +1,170 @@
+package filesys
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is a single pattern of a .gitignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreFile holds the rules of a .gitignore file. They apply to the paths
+// below dir.
+type ignoreFile struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// readIgnoreFile parses the .gitignore file in dir. It returns false if
+// there is none or it has no rules.
+func readIgnoreFile(dir string) (ignoreFile, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return ignoreFile{}, false
+	}
+	rules := parseIgnore(data)
+	return ignoreFile{dir: dir, rules: rules}, len(rules) > 0
+}
+
+// parseIgnore parses the patterns of a .gitignore file. Invalid patterns
+// are skipped.
+func parseIgnore(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// Patterns without a slash match at any depth, the others are
+		// relative to the directory of the .gitignore
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		re, err := regexp.Compile("^" + globToRegexp(strings.TrimPrefix(line, "/")) + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp translates a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				rest := glob[i+2:]
+				switch {
+				case strings.HasPrefix(rest, "/"):
+					// "**/" matches zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				case rest == "":
+					sb.WriteString(".*")
+					i++
+				default:
+					sb.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// ignored reports whether path is ignored by the given files, which must be
+// ordered from the outermost directory to the innermost. As in git, the
+// last matching rule decides.
+func ignored(files []ignoreFile, path string, isDir bool) bool {
+	ignore := false
+	for _, f := range files {
+		rel, err := filepath.Rel(f.dir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range f.rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(rel) {
+				ignore = !rule.negate
+			}
+		}
+	}
+	return ignore
+}
+
+// parentIgnoreFiles returns the .gitignore files of the directories above
+// root, up to the root of its git repository. None are returned if root is
+// not inside a repository.
+func parentIgnoreFiles(root string) []ignoreFile {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		return nil
+	}
+
+	var files []ignoreFile
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		if f, ok := readIgnoreFile(dir); ok {
+			files = append([]ignoreFile{f}, files...)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return files
+		}
+		if dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// JobKind identifies the operation a job performs.
+type JobKind int
+
+const (
+	JobCopy JobKind = iota
+	JobMove
+	JobTrash
+	JobDelete
+	JobLink
+	JobCompare
+)
+
+func (k JobKind) String() string {
+	switch k {
+	case JobCopy:
+		return "copy"
+	case JobMove:
+		return "move"
+	case JobTrash:
+		return "trash"
+	case JobDelete:
+		return "delete"
+	case JobLink:
+		return "link"
+	case JobCompare:
+		return "compare"
+	}
+	return "unknown"
+}
+
+const (
+	// jobQueueSize is the number of jobs that can wait for a worker before
+	// submitting blocks.
+	jobQueueSize = 64
+	// jobProgressInterval is how often a running job reports its progress.
+	jobProgressInterval = 200 * time.Millisecond
+)
+
+// JobInfo describes a submitted job.
+type JobInfo struct {
+	ID    int
+	Kind  JobKind
+	Paths []string
+	// Dst is the destination directory of copies and moves.
+	Dst string
+	// Opts holds the paste options of copies, moves and links. Compare jobs
+	// keep their hash in Opts.Verify.
+	Opts PasteOptions
+	// Link is the kind of links created by link jobs.
+	Link LinkKind
+}
+
+// JobQueuedMsg is sent when a job is submitted.
+type JobQueuedMsg struct {
+	Job JobInfo
+}
+
+// JobStartedMsg is sent when a worker picks up a job.
+type JobStartedMsg struct {
+	ID   int
+	Kind JobKind
+}
+
+// JobProgressMsg is sent periodically while a job is running.
+type JobProgressMsg struct {
+	ID   int
+	Kind JobKind
+	Stat ProgressStat
+}
+
+// JobFinishedMsg is sent when a job is done, successfully or not.
+type JobFinishedMsg struct {
+	ID   int
+	Kind JobKind
+	Stat ProgressStat
+	Err  error
+	// Remaining holds the paths that were not handled if the job failed.
+	Remaining []string
+}
+
+// JobCancelledError is returned by a job that was cancelled before it
+// finished.
+type JobCancelledError struct {
+	Kind  JobKind
+	Done  int
+	Total int
+}
+
+func (e JobCancelledError) Error() string {
+	return fmt.Sprintf("%s cancelled, %d of %d items done", e.Kind, e.Done, e.Total)
+}
+
+func (e JobCancelledError) Unwrap() error {
+	return context.Canceled
+}
+
+type job struct {
+	JobInfo
+	progress *Progress
+	// run performs the operation, returning the ops to journal.
+	run func(ctx context.Context, p *Progress) ([]Op, error)
+	// total calculates the amount of work the job will do. It runs
+	// alongside the job and should stop once ctx is done.
+	total func(ctx context.Context) (files, bytes int64)
+	done  chan error
+}
+
+// Jobs runs file operations in the background. A single worker executes
+// the jobs in the order they are submitted, so an operation always sees the
+// result of the ones submitted before it, at the cost of waiting for them.
+// Completed operations are recorded in the journal.
+//
+// Progress is reported through messages read by Listen.
+type Jobs struct {
+	journal *Journal
+	queue   chan *job
+	events  chan tea.Msg
+	nextID  atomic.Int64
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // cancels the running job
+}
+
+func NewJobs(journal *Journal) *Jobs {
+	js := &Jobs{
+		journal: journal,
+		queue:   make(chan *job, jobQueueSize),
+		events:  make(chan tea.Msg, jobQueueSize),
+	}
+	go js.work()
+	return js
+}
+
+// Listen waits for the next job event. It must be called again after each
+// JobQueuedMsg, JobStartedMsg, JobProgressMsg and JobFinishedMsg to keep
+// receiving them.
+func (js *Jobs) Listen() tea.Cmd {
+	return func() tea.Msg {
+		return <-js.events
+	}
+}
+
+// Cancel stops the running job. Queued jobs are left alone. It reports
+// whether there was a job to cancel.
+func (js *Jobs) Cancel() bool {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	if js.cancel == nil {
+		return false
+	}
+	js.cancel()
+	js.cancel = nil
+	return true
+}
+
+// CopyCmd copies paths into dst.
+func (js *Jobs) CopyCmd(paths []string, dst string, opts PasteOptions) tea.Cmd {
+	info := JobInfo{Kind: JobCopy, Paths: paths, Dst: dst, Opts: opts}
+	return js.submit(info, FilesCopiedMsg{Paths: paths},
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			return CopyPaths(ctx, paths, dst, opts, p)
+		},
+		func(ctx context.Context) (int64, int64) {
+			files, bytes := countTree(ctx, paths)
+			if opts.Verify != HashNone {
+				// The copies are read back once written
+				bytes *= 2
+			}
+			return files, bytes
+		})
+}
+
+// MoveCmd moves paths into dst.
+func (js *Jobs) MoveCmd(paths []string, dst string, opts PasteOptions) tea.Cmd {
+	info := JobInfo{Kind: JobMove, Paths: paths, Dst: dst, Opts: opts}
+	return js.submit(info, FilesMovedMsg{Paths: paths},
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			return MovePaths(ctx, paths, dst, opts, p)
+		},
+		func(ctx context.Context) (int64, int64) {
+			return countMove(ctx, paths, dst)
+		})
+}
+
+// TrashCmd moves paths to the trash.
+func (js *Jobs) TrashCmd(paths []string) tea.Cmd {
+	return js.submit(JobInfo{Kind: JobTrash, Paths: paths}, FilesDeletedMsg{Paths: paths},
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			return TrashPaths(ctx, paths, p)
+		},
+		countItems(paths))
+}
+
+// DeleteCmd permanently deletes paths. This cannot be undone.
+func (js *Jobs) DeleteCmd(paths []string) tea.Cmd {
+	return js.submit(JobInfo{Kind: JobDelete, Paths: paths}, FilesDeletedMsg{Paths: paths},
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			return nil, DeletePaths(ctx, paths, p)
+		},
+		countItems(paths))
+}
+
+// LinkCmd creates links to paths inside dst.
+func (js *Jobs) LinkCmd(paths []string, dst string, kind LinkKind, opts PasteOptions) tea.Cmd {
+	info := JobInfo{Kind: JobLink, Paths: paths, Dst: dst, Opts: opts, Link: kind}
+	return js.submit(info, FilesLinkedMsg{Paths: paths},
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			return LinkPaths(ctx, paths, dst, kind, opts, p)
+		},
+		countItems(paths))
+}
+
+// CompareCmd hashes paths to tell whether their contents match.
+func (js *Jobs) CompareCmd(algo HashAlgo, paths []string) tea.Cmd {
+	info := JobInfo{Kind: JobCompare, Paths: paths, Opts: PasteOptions{Verify: algo}}
+	var c Comparison
+	done := func() tea.Msg {
+		if c.Paths == nil {
+			// There is no result if the comparison failed
+			return nil
+		}
+		return FilesComparedMsg{Comparison: c}
+	}
+	return js.submitFunc(info, done,
+		func(ctx context.Context, p *Progress) ([]Op, error) {
+			var err error
+			c, err = CompareFiles(ctx, algo, paths, p)
+			return nil, err
+		},
+		func(ctx context.Context) (int64, int64) {
+			return countTree(ctx, paths)
+		})
+}
+
+// RetryCmd submits a failed job again for the paths it did not get to.
+func (js *Jobs) RetryCmd(info JobInfo, remaining []string) tea.Cmd {
+	switch info.Kind {
+	case JobCopy:
+		return js.CopyCmd(remaining, info.Dst, info.Opts)
+	case JobMove:
+		return js.MoveCmd(remaining, info.Dst, info.Opts)
+	case JobTrash:
+		return js.TrashCmd(remaining)
+	case JobDelete:
+		return js.DeleteCmd(remaining)
+	case JobLink:
+		return js.LinkCmd(remaining, info.Dst, info.Link, info.Opts)
+	case JobCompare:
+		// Files are only compared with each other, so all of them are
+		// hashed again
+		return js.CompareCmd(info.Opts.Verify, info.Paths)
+	}
+	return nil
+}
+
+// submit queues a job and returns a command that waits for it to finish.
+// done is returned even if the job failed part way, since some paths may
+// have changed.
+func (js *Jobs) submit(info JobInfo, done tea.Msg, run func(context.Context, *Progress) ([]Op, error), total func(context.Context) (int64, int64)) tea.Cmd {
+	return js.submitFunc(info, func() tea.Msg { return done }, run, total)
+}
+
+// submitFunc is like submit, but the message sent once the job is done is
+// returned by done, which is only called after run has returned.
+func (js *Jobs) submitFunc(info JobInfo, done func() tea.Msg, run func(context.Context, *Progress) ([]Op, error), total func(context.Context) (int64, int64)) tea.Cmd {
+	return func() tea.Msg {
+		info.ID = int(js.nextID.Add(1))
+		j := &job{
+			JobInfo:  info,
+			progress: &Progress{},
+			run:      run,
+			total:    total,
+			done:     make(chan error, 1),
+		}
+		js.events <- JobQueuedMsg{Job: info}
+		js.queue <- j
+		if err := <-j.done; err != nil {
+			return tea.BatchMsg{
+				func() tea.Msg { return err },
+				done,
+			}
+		}
+		return done()
+	}
+}
+
+func (js *Jobs) work() {
+	for j := range js.queue {
+		js.events <- JobStartedMsg{ID: j.ID, Kind: j.Kind}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		js.mu.Lock()
+		js.cancel = cancel
+		js.mu.Unlock()
+
+		stop := make(chan struct{})
+		go js.report(j, stop)
+
+		// Counting a large tree takes a while, so the job starts right away
+		// and its total is filled in once known
+		go func() {
+			files, bytes := j.total(ctx)
+			if ctx.Err() == nil {
+				j.progress.SetTotal(files, bytes)
+			}
+		}()
+		ops, err := j.run(ctx, j.progress)
+		close(stop)
+
+		js.mu.Lock()
+		js.cancel = nil
+		js.mu.Unlock()
+		cancel()
+
+		var remaining []string
+		if err != nil {
+			remaining = j.remaining()
+		}
+		if errors.Is(err, context.Canceled) {
+			total := len(unique(j.Paths))
+			err = JobCancelledError{Kind: j.Kind, Done: total - len(remaining), Total: total}
+		}
+
+		js.journal.Record(j.Kind.String(), ops)
+		js.events <- JobFinishedMsg{ID: j.ID, Kind: j.Kind, Stat: j.progress.Stat(), Err: err, Remaining: remaining}
+		j.done <- err
+	}
+}
+
+// remaining returns the paths the job did not handle. Paths skipped by a
+// conflict resolution count as handled, so a retry leaves them alone.
+func (j *job) remaining() []string {
+	return slices.DeleteFunc(unique(j.Paths), j.progress.IsHandled)
+}
+
+// report sends the progress of j until stop is closed. Updates are dropped
+// rather than blocking the job if nobody is listening.
+func (js *Jobs) report(j *job, stop <-chan struct{}) {
+	t := time.NewTicker(jobProgressInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			select {
+			case js.events <- JobProgressMsg{ID: j.ID, Kind: j.Kind, Stat: j.progress.Stat()}:
+			default:
+			}
+		}
+	}
+}
+
+// countItems returns a total function counting each path as one file.
+func countItems(paths []string) func(context.Context) (int64, int64) {
+	return func(context.Context) (int64, int64) {
+		return int64(len(unique(paths))), 0
+	}
+}
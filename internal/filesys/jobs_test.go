@@ -0,0 +1,188 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestJobsCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{src, dst} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := NewJournal()
+	js := NewJobs(j)
+	msg := js.CopyCmd([]string{src}, dst, PasteOptions{})()
+	if _, ok := msg.(FilesCopiedMsg); !ok {
+		t.Fatalf("got %#v, want FilesCopiedMsg", msg)
+	}
+	assertExists(t, filepath.Join(dst, "src", "a"), true)
+	assertExists(t, filepath.Join(dst, "src", "b"), true)
+
+	reg := NewJobRegistry()
+	for ev := range js.events {
+		reg.Update(ev)
+		if _, ok := ev.(JobFinishedMsg); ok {
+			break
+		}
+	}
+	recs := reg.Records()
+	if len(recs) != 1 || recs[0].Kind != JobCopy || recs[0].State != JobDone {
+		t.Fatalf("got records %+v, want one finished copy", recs)
+	}
+	if recs[0].Stat.FilesDone != 2 || recs[0].Dst != dst {
+		t.Errorf("got record %+v, want 2 files copied to %q", recs[0], dst)
+	}
+	if !reg.Dismiss(recs[0].ID) || len(reg.Records()) != 0 {
+		t.Error("expected finished job to be dismissed")
+	}
+
+	if _, err := j.Undo(); err != nil {
+		t.Fatalf("undo copy: %v", err)
+	}
+	assertExists(t, filepath.Join(dst, "src"), false)
+}
+
+func TestProgressPercent(t *testing.T) {
+	p := &Progress{}
+	p.SetTotal(4, 0)
+	p.AddFiles(1)
+	if got := p.Stat().Percent(); got != 25 {
+		t.Errorf("percent by files = %d, want 25", got)
+	}
+
+	p.SetTotal(4, 200)
+	p.AddBytes(150)
+	if got := p.Stat().Percent(); got != 75 {
+		t.Errorf("percent by bytes = %d, want 75", got)
+	}
+
+	var nilProgress *Progress
+	nilProgress.AddFiles(1)
+	if got := nilProgress.Stat(); got != (ProgressStat{}) {
+		t.Errorf("nil progress stat = %+v", got)
+	}
+}
+
+func TestCopyCancelCleansUp(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{filepath.Join(src, "tree"), dst} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(src, "first"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Large enough that the copy is still running when it is cancelled
+	if err := os.WriteFile(filepath.Join(src, "tree", "large"), make([]byte, 64<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var p Progress
+	go func() {
+		// Cancel as soon as the large file has started copying
+		for ctx.Err() == nil && p.Stat().BytesDone <= int64(len("data")) {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+
+	paths := []string{filepath.Join(src, "first"), filepath.Join(src, "tree")}
+	ops, err := CopyPaths(ctx, paths, dst, PasteOptions{}, &p)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	if len(ops) != 1 || ops[0].Dst != filepath.Join(dst, "first") {
+		t.Fatalf("got ops %+v, want only the first copy", ops)
+	}
+	assertExists(t, filepath.Join(dst, "first"), true)
+	assertExists(t, filepath.Join(dst, "tree"), false)
+}
+
+func TestJobRemaining(t *testing.T) {
+	j := &job{JobInfo: JobInfo{Kind: JobCopy, Paths: []string{"a", "b", "c", "b"}}, progress: &Progress{}}
+	j.progress.Handled("a")
+	if got := j.remaining(); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("remaining = %v, want [b c]", got)
+	}
+}
+
+func TestJobSkipsAreHandled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, name := range []string{"skip", "copy"} {
+		for _, dir := range []string{src, dst} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(dir), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Copying "copy" fails on the unresolved conflict, after "skip" was
+	// skipped
+	paths := []string{filepath.Join(src, "skip"), filepath.Join(src, "copy")}
+	opts := PasteOptions{Resolutions: map[string]Resolution{paths[0]: Skip}}
+
+	js := NewJobs(NewJournal())
+	go js.CopyCmd(paths, dst, opts)()
+	for ev := range js.events {
+		msg, ok := ev.(JobFinishedMsg)
+		if !ok {
+			continue
+		}
+		if msg.Err == nil || !slices.Equal(msg.Remaining, paths[1:]) {
+			t.Fatalf("got %v remaining after %v, want only the conflicting path", msg.Remaining, msg.Err)
+		}
+		break
+	}
+}
+
+func TestJobsCompare(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	js := NewJobs(NewJournal())
+	msg, ok := js.CompareCmd(HashSHA256, paths)().(FilesComparedMsg)
+	if !ok || !msg.Comparison.Identical() {
+		t.Fatalf("got %#v, want identical files", msg)
+	}
+
+	msg2 := js.CompareCmd(HashSHA256, []string{paths[0]})()
+	batch, ok := msg2.(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("got %#v, want an error and no result", msg2)
+	}
+	if _, ok := batch[0]().(error); !ok {
+		t.Errorf("got %#v, want an error", batch[0]())
+	}
+	if got := batch[1](); got != nil {
+		t.Errorf("got %#v, want no result", got)
+	}
+}
@@ -0,0 +1,370 @@
+package filesys
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// OpKind identifies the kind of a journaled operation.
+type OpKind int
+
+const (
+	// OpMove moves or renames Src to Dst.
+	OpMove OpKind = iota
+	// OpCopy copies Src to Dst.
+	OpCopy
+	// OpCreate creates Dst, which is a directory if Dir is set.
+	OpCreate
+	// OpTrash moves Src to Dst inside the trash.
+	OpTrash
+	// OpLink creates a link of kind Link at Dst pointing to Src.
+	OpLink
+)
+
+// Op is a single reversible filesystem change.
+type Op struct {
+	Kind OpKind
+	Src  string
+	Dst  string
+	Dir  bool
+	// Archive is set on copies that preserved metadata.
+	Archive bool
+	Link    LinkKind
+	// Stamp fingerprints a copy once it is made, so that undoing the copy
+	// does not throw away later changes to it. See treeStamp.
+	Stamp string
+}
+
+// Entry groups the operations performed by a single user action.
+type Entry struct {
+	Label string
+	Ops   []Op
+}
+
+// maxJournalEntries is the number of actions that can be undone.
+const maxJournalEntries = 100
+
+// Journal records file operations so they can be undone and redone.
+// Deletions are only undoable if they went through the trash.
+//
+// Recording into a nil *Journal is a no-op.
+type Journal struct {
+	mu   sync.Mutex
+	undo []Entry
+	redo []Entry
+}
+
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+// Record adds an action to the journal. Recording a new action discards
+// everything that could be redone.
+func (j *Journal) Record(label string, ops []Op) {
+	if j == nil || len(ops) == 0 {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.undo = append(j.undo, Entry{Label: label, Ops: ops})
+	j.redo = nil
+
+	if n := len(j.undo) - maxJournalEntries; n > 0 {
+		j.undo = slices.Delete(j.undo, 0, n)
+	}
+}
+
+// Undo reverts the most recent action. The filesystem is checked before
+// anything is touched, and the undo is refused if it conflicts with changes
+// made since the action was recorded.
+func (j *Journal) Undo() (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.undo) == 0 {
+		return Entry{}, errors.New("nothing to undo")
+	}
+
+	e := j.undo[len(j.undo)-1]
+	ops := slices.Clone(e.Ops)
+	slices.Reverse(ops)
+
+	done, err := replay(ops, true)
+	if done > 0 {
+		// Move whatever was reverted over to the redo stack
+		n := len(e.Ops) - done
+		j.redo = append(j.redo, Entry{Label: e.Label, Ops: e.Ops[n:]})
+		if n == 0 {
+			j.undo = j.undo[:len(j.undo)-1]
+		} else {
+			j.undo[len(j.undo)-1].Ops = e.Ops[:n]
+		}
+	}
+	return e, err
+}
+
+// Redo reapplies the most recently undone action.
+func (j *Journal) Redo() (Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.redo) == 0 {
+		return Entry{}, errors.New("nothing to redo")
+	}
+
+	e := j.redo[len(j.redo)-1]
+
+	done, err := replay(e.Ops, false)
+	if done > 0 {
+		j.undo = append(j.undo, Entry{Label: e.Label, Ops: e.Ops[:done]})
+		if done == len(e.Ops) {
+			j.redo = j.redo[:len(j.redo)-1]
+		} else {
+			j.redo[len(j.redo)-1].Ops = e.Ops[done:]
+		}
+	}
+	return e, err
+}
+
+// replay checks and then applies ops in order, reverting each op if undo is
+// set. It returns the number of ops that were applied.
+func replay(ops []Op, undo bool) (int, error) {
+	action := "redo"
+	if undo {
+		action = "undo"
+	}
+
+	view := make(fsView)
+	for _, op := range ops {
+		if err := view.check(op, undo); err != nil {
+			return 0, fmt.Errorf("cannot %s: %w", action, err)
+		}
+	}
+
+	for i := range ops {
+		if err := apply(&ops[i], undo); err != nil {
+			return i, fmt.Errorf("cannot %s: %w", action, err)
+		}
+	}
+	return len(ops), nil
+}
+
+// apply performs op, or its inverse if undo is set. Trashing again may pick
+// another name inside the trash, so op is updated in place.
+func apply(op *Op, undo bool) error {
+	switch op.Kind {
+	case OpMove:
+		from, to := op.Src, op.Dst
+		if undo {
+			from, to = to, from
+		}
+		return movePath(context.Background(), from, to)
+
+	case OpTrash:
+		if undo {
+			return restoreTrashed(op.Dst, op.Src)
+		}
+		trashed, err := trashPath(op.Src)
+		if err != nil {
+			return err
+		}
+		op.Dst = trashed
+		return nil
+
+	case OpCopy:
+		if undo {
+			return os.RemoveAll(op.Dst)
+		}
+		if err := newCopier(context.Background(), nil, op.Archive).copy(op.Src, op.Dst); err != nil {
+			return err
+		}
+		op.Stamp = stampCopy(op.Dst)
+		return nil
+
+	case OpLink:
+		if undo {
+			return os.Remove(op.Dst)
+		}
+		src, err := filepath.Abs(op.Src)
+		if err != nil {
+			return err
+		}
+		return createLink(src, op.Dst, op.Link)
+
+	case OpCreate:
+		if undo {
+			return os.Remove(op.Dst)
+		}
+		if op.Dir {
+			return os.Mkdir(op.Dst, 0o777)
+		}
+		f, err := os.OpenFile(op.Dst, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	return fmt.Errorf("unknown operation %d", op.Kind)
+}
+
+// fsView tracks which paths exist while a sequence of ops is checked,
+// overlaying the changes made by the earlier ops onto the filesystem.
+type fsView map[string]bool
+
+func (v fsView) exists(path string) bool {
+	if ok, seen := v[path]; seen {
+		return ok
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func (v fsView) check(op Op, undo bool) error {
+	switch op.Kind {
+	case OpMove, OpTrash:
+		from, to := op.Src, op.Dst
+		if undo {
+			from, to = to, from
+		}
+		if op.Kind == OpTrash && !undo {
+			// A fresh name is picked inside the trash
+			if !v.exists(from) {
+				return fmt.Errorf("%q no longer exists", from)
+			}
+			v[from] = false
+			return nil
+		}
+		if err := v.requireFree(to); err != nil {
+			return err
+		}
+		if !v.exists(from) {
+			return fmt.Errorf("%q no longer exists", from)
+		}
+		v[from], v[to] = false, true
+
+	case OpCopy, OpLink:
+		if undo {
+			if !v.exists(op.Dst) {
+				return fmt.Errorf("%q no longer exists", op.Dst)
+			}
+			if _, seen := v[op.Dst]; !seen && op.Stamp != "" {
+				if stamp, err := treeStamp(op.Dst); err != nil || stamp != op.Stamp {
+					return fmt.Errorf("%q has been modified", op.Dst)
+				}
+			}
+			v[op.Dst] = false
+			return nil
+		}
+		if !v.exists(op.Src) {
+			return fmt.Errorf("%q no longer exists", op.Src)
+		}
+		if err := v.requireFree(op.Dst); err != nil {
+			return err
+		}
+		v[op.Dst] = true
+
+	case OpCreate:
+		if !undo {
+			if err := v.requireFree(op.Dst); err != nil {
+				return err
+			}
+			v[op.Dst] = true
+			return nil
+		}
+		if !v.exists(op.Dst) {
+			return fmt.Errorf("%q no longer exists", op.Dst)
+		}
+		if _, seen := v[op.Dst]; !seen && !op.Dir {
+			if info, err := os.Lstat(op.Dst); err == nil && info.Size() > 0 {
+				return fmt.Errorf("%q has been modified", op.Dst)
+			}
+		}
+		if op.Dir && !v.empty(op.Dst) {
+			return fmt.Errorf("%q is not empty", op.Dst)
+		}
+		v[op.Dst] = false
+	}
+	return nil
+}
+
+// empty reports whether the directory dir has no entries left once the
+// earlier ops are taken into account.
+func (v fsView) empty(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return false
+	}
+	for _, e := range entries {
+		if v.exists(filepath.Join(dir, e.Name())) {
+			return false
+		}
+	}
+	for path, ok := range v {
+		if ok && filepath.Dir(path) == dir {
+			return false
+		}
+	}
+	return true
+}
+
+// requireFree checks that path does not exist but its parent does.
+func (v fsView) requireFree(path string) error {
+	if v.exists(path) {
+		return fmt.Errorf("%q already exists", path)
+	}
+	if dir := filepath.Dir(path); !v.exists(dir) {
+		return fmt.Errorf("%q no longer exists", dir)
+	}
+	return nil
+}
+
+// treeStamp fingerprints the tree at path from the names, modes, sizes and
+// modification times of its entries. The times of directories are left out,
+// since moving a directory may update them.
+func treeStamp(path string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%v", rel, info.Mode())
+		if !d.IsDir() {
+			fmt.Fprintf(h, "\x00%d\x00%d", info.Size(), info.ModTime().UnixNano())
+		}
+		h.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stampCopy returns the stamp of a copy at path. Copies that cannot be
+// stamped are undone without checking them.
+func stampCopy(path string) string {
+	stamp, err := treeStamp(path)
+	if err != nil {
+		slog.Warn("Failed to stamp copy, undo will not check it", "error", err, "path", path)
+	}
+	return stamp
+}
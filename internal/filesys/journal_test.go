@@ -0,0 +1,131 @@
+package filesys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalUndoRedo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	j := NewJournal()
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := MovePaths(context.Background(), []string{src}, dst, PasteOptions{}, nil)
+	if err != nil {
+		t.Fatalf("MovePaths failed: %v", err)
+	}
+	j.Record("move", ops)
+
+	moved := filepath.Join(dst, "src")
+	ops, err = TrashPaths(context.Background(), []string{moved}, nil)
+	if err != nil {
+		t.Fatalf("TrashPaths failed: %v", err)
+	}
+	j.Record("trash", ops)
+	assertExists(t, moved, false)
+
+	// Undo the trash, then the move
+	if _, err := j.Undo(); err != nil {
+		t.Fatalf("undo trash: %v", err)
+	}
+	assertExists(t, moved, true)
+	if _, err := j.Undo(); err != nil {
+		t.Fatalf("undo move: %v", err)
+	}
+	assertExists(t, src, true)
+	assertExists(t, moved, false)
+
+	if _, err := j.Redo(); err != nil {
+		t.Fatalf("redo move: %v", err)
+	}
+	assertExists(t, moved, true)
+
+	// A conflicting file must make the undo fail without touching anything
+	if err := os.WriteFile(src, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(); err == nil {
+		t.Fatal("expected conflicting undo to fail")
+	}
+	assertExists(t, moved, true)
+	if data, _ := os.ReadFile(src); string(data) != "new" {
+		t.Fatalf("conflicting file was modified: %q", data)
+	}
+}
+
+func TestJournalUndoRefusesChanges(t *testing.T) {
+	dir := t.TempDir()
+	j := NewJournal()
+
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := CopyPaths(context.Background(), []string{src}, dst, PasteOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Record("copy", ops)
+
+	// An edited copy must not be removed
+	copied := filepath.Join(dst, "src")
+	if err := os.WriteFile(copied, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(); err == nil {
+		t.Fatal("expected undoing an edited copy to fail")
+	}
+	if data, _ := os.ReadFile(copied); string(data) != "edited" {
+		t.Fatalf("edited copy was changed: %q", data)
+	}
+
+	// Neither must a directory that was filled since it was created
+	j = NewJournal()
+	created, err := Mkdir(filepath.Join(dir, "new", "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Record("create", createOps(created, ""))
+	if err := os.WriteFile(filepath.Join(dir, "new", "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(); err == nil {
+		t.Fatal("expected undoing a filled directory to fail")
+	}
+	assertExists(t, filepath.Join(dir, "new", "sub"), true)
+
+	// Once emptied again, the nested directories are removed together
+	if err := os.Remove(filepath.Join(dir, "new", "file")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(); err != nil {
+		t.Fatalf("undo create: %v", err)
+	}
+	assertExists(t, filepath.Join(dir, "new"), false)
+}
+
+func assertExists(t *testing.T, path string, exists bool) {
+	t.Helper()
+	_, err := os.Lstat(path)
+	if exists && err != nil {
+		t.Fatalf("expected %q to exist: %v", path, err)
+	}
+	if !exists && err == nil {
+		t.Fatalf("expected %q to not exist", path)
+	}
+}
@@ -0,0 +1,129 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// LinkKind selects the kind of link created by LinkPaths.
+type LinkKind int
+
+const (
+	// SymlinkAbsolute creates symlinks holding the absolute source path.
+	SymlinkAbsolute LinkKind = iota
+	// SymlinkRelative creates symlinks holding the source path relative to
+	// the link.
+	SymlinkRelative
+	// Hardlink creates hard links, which only works on the same device.
+	Hardlink
+)
+
+func (k LinkKind) String() string {
+	switch k {
+	case SymlinkAbsolute, SymlinkRelative:
+		return "symlink"
+	case Hardlink:
+		return "hardlink"
+	}
+	return "link"
+}
+
+// LinkConflicts returns the collisions linking paths into dst would cause.
+func LinkConflicts(paths []string, dst string) []Conflict {
+	return conflicts(paths, func(path string) string {
+		return linkTarget(path, dst)
+	})
+}
+
+// linkTarget returns the path of the link to src created in dstDir.
+// Linking an entry into its own directory adds a "_link" suffix.
+func linkTarget(src, dstDir string) string {
+	return pasteTarget(src, dstDir, "_link")
+}
+
+// LinkPaths creates links to paths inside dst.
+func LinkPaths(ctx context.Context, paths []string, dst string, kind LinkKind, opts PasteOptions, p *Progress) ([]Op, error) {
+	ops := make([]Op, 0, len(paths))
+	for _, path := range unique(paths) {
+		if err := ctx.Err(); err != nil {
+			return ops, err
+		}
+
+		src, err := filepath.Abs(path)
+		if err != nil {
+			return ops, err
+		}
+		if err := checkLinkSource(src, kind); err != nil {
+			return ops, err
+		}
+
+		target, r, ok, err := opts.resolve(path, linkTarget(path, dst))
+		if err != nil {
+			return ops, err
+		}
+		if !ok {
+			p.Handled(path)
+			continue
+		}
+
+		if err := createLink(src, target, kind); err != nil {
+			return ops, err
+		}
+		placed, replaced, err := r.commit(target)
+		if err != nil {
+			if err2 := os.Remove(target); err2 != nil {
+				slog.Error("Failed to remove link", "error", err2, "path", target)
+			}
+			return ops, err
+		}
+		slog.Info("Linked", "path", src, "link", placed, "kind", kind)
+		ops = append(ops, replaced...)
+		ops = append(ops, Op{Kind: OpLink, Src: path, Dst: placed, Link: kind})
+		p.AddFiles(1)
+		p.Handled(path)
+	}
+	return ops, nil
+}
+
+// checkLinkSource refuses to create links that would be broken or that the
+// filesystem does not allow.
+func checkLinkSource(src string, kind LinkKind) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("%q is a broken link", src)
+		}
+	}
+	if kind == Hardlink && info.IsDir() {
+		return fmt.Errorf("cannot hardlink directory %q", src)
+	}
+	return nil
+}
+
+// createLink creates a link of the given kind at dst pointing to the
+// absolute path src.
+func createLink(src, dst string, kind LinkKind) error {
+	switch kind {
+	case SymlinkRelative:
+		rel, err := filepath.Rel(filepath.Dir(dst), src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(rel, dst)
+	case Hardlink:
+		err := os.Link(src, dst)
+		if errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("cannot hardlink %q across devices", src)
+		}
+		return err
+	}
+	return os.Symlink(src, dst)
+}
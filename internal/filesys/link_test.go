@@ -0,0 +1,88 @@
+package filesys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkPaths(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "file")
+	dst := filepath.Join(dir, "dst")
+	for _, d := range []string{filepath.Dir(src), dst} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind   LinkKind
+		dir    string
+		target string // expected symlink contents
+	}{
+		{SymlinkAbsolute, "abs", src},
+		{SymlinkRelative, "rel", filepath.Join("..", "..", "src", "file")},
+		{Hardlink, "hard", ""},
+	}
+	j := NewJournal()
+	for _, tt := range tests {
+		linkDir := filepath.Join(dst, tt.dir)
+		if err := os.Mkdir(linkDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		ops, err := LinkPaths(context.Background(), []string{src}, linkDir, tt.kind, PasteOptions{}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.dir, err)
+		}
+		j.Record("link", ops)
+
+		link := filepath.Join(linkDir, "file")
+		if data, err := os.ReadFile(link); err != nil || string(data) != "data" {
+			t.Errorf("%s: reading through link: %q, %v", tt.dir, data, err)
+		}
+		if tt.target != "" {
+			if got, err := os.Readlink(link); err != nil || got != tt.target {
+				t.Errorf("%s: link points to %q, %v, want %q", tt.dir, got, err, tt.target)
+			}
+		}
+
+		if _, err := j.Undo(); err != nil {
+			t.Fatalf("%s: undo: %v", tt.dir, err)
+		}
+		assertExists(t, link, false)
+		if _, err := j.Redo(); err != nil {
+			t.Fatalf("%s: redo: %v", tt.dir, err)
+		}
+		assertExists(t, link, true)
+	}
+
+	// Linking in place adds a suffix
+	if _, err := LinkPaths(context.Background(), []string{src}, filepath.Dir(src), SymlinkAbsolute, PasteOptions{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, filepath.Join(dir, "src", "file_link"), true)
+}
+
+func TestLinkRefusesBrokenSource(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken")
+	if err := os.Symlink(filepath.Join(dir, "missing"), broken); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+	if err := os.Mkdir(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LinkPaths(context.Background(), []string{broken}, dst, SymlinkAbsolute, PasteOptions{}, nil); err == nil {
+		t.Fatal("expected linking to a broken link to fail")
+	}
+	if _, err := LinkPaths(context.Background(), []string{dir}, dst, Hardlink, PasteOptions{}, nil); err == nil {
+		t.Fatal("expected hard linking a directory to fail")
+	}
+}
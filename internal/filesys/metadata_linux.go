@@ -0,0 +1,107 @@
+//go:build linux
+
+package filesys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyMetadata copies the ownership, permissions, extended attributes and
+// timestamps of src, described by info, to dst. Ownership and attributes
+// that need privileges the process lacks are skipped.
+func copyMetadata(src, dst string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Lchown(dst, int(st.Uid), int(st.Gid)); err != nil && !skippable(err) {
+		return err
+	}
+	if err := copyXattrs(src, dst); err != nil {
+		return err
+	}
+	// Changing the owner clears the setuid and setgid bits, so the mode
+	// is set afterwards
+	if info.Mode()&os.ModeSymlink == 0 {
+		if err := os.Chmod(dst, info.Mode()); err != nil {
+			return err
+		}
+	}
+
+	times := []unix.Timespec{
+		{Sec: st.Atim.Sec, Nsec: st.Atim.Nsec},
+		{Sec: st.Mtim.Sec, Nsec: st.Mtim.Nsec},
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, dst, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+func copyXattrs(src, dst string) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		if skippable(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err != nil {
+			if skippable(err) || errors.Is(err, unix.ENODATA) {
+				continue
+			}
+			return fmt.Errorf("read attribute %s of %q: %w", name, src, err)
+		}
+		if err := unix.Lsetxattr(dst, name, value, 0); err != nil && !skippable(err) {
+			return fmt.Errorf("set attribute %s on %q: %w", name, dst, err)
+		}
+	}
+	return nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range strings.Split(string(buf[:n]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := unix.Lgetxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// skippable reports whether err means the filesystem or the process's
+// privileges do not allow preserving a piece of metadata.
+func skippable(err error) bool {
+	return errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) ||
+		errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP)
+}
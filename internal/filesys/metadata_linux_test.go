@@ -0,0 +1,31 @@
+package filesys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestArchiveCopyXattrs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, []byte("data"), 0o400); err != nil {
+		t.Fatal(err)
+	}
+	if err := unix.Setxattr(src, "user.sail", []byte("value"), 0); err != nil {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := newCopier(context.Background(), nil, true).copy(src, dst); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	value, err := getXattr(dst, "user.sail")
+	if err != nil || string(value) != "value" {
+		t.Errorf("got attribute %q, %v, want %q", value, err, "value")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package filesys
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// copyMetadata copies the permissions and modification time of src,
+// described by info, to dst. Ownership and extended attributes are only
+// preserved on Linux.
+func copyMetadata(_, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	if err := os.Chmod(dst, info.Mode()); err != nil && !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
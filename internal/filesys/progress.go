@@ -0,0 +1,114 @@
+package filesys
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Progress tracks how far a file operation has come. It is safe for
+// concurrent use, and a nil *Progress ignores all updates.
+type Progress struct {
+	filesDone  atomic.Int64
+	filesTotal atomic.Int64
+	bytesDone  atomic.Int64
+	bytesTotal atomic.Int64
+
+	mu      sync.Mutex
+	handled map[string]bool // paths given to the operation that it is done with
+}
+
+// ProgressStat is a snapshot of a Progress.
+type ProgressStat struct {
+	FilesDone  int64
+	FilesTotal int64
+	BytesDone  int64
+	BytesTotal int64
+}
+
+// Percent returns the completion in percent, based on bytes if the total
+// size is known and on files otherwise.
+func (s ProgressStat) Percent() int {
+	switch {
+	case s.BytesTotal > 0:
+		return int(min(100, s.BytesDone*100/s.BytesTotal))
+	case s.FilesTotal > 0:
+		return int(min(100, s.FilesDone*100/s.FilesTotal))
+	}
+	return 0
+}
+
+// SetTotal sets the amount of work the operation is expected to do.
+func (p *Progress) SetTotal(files, bytes int64) {
+	if p == nil {
+		return
+	}
+	p.filesTotal.Store(files)
+	p.bytesTotal.Store(bytes)
+}
+
+// AddFiles records that n more files are done.
+func (p *Progress) AddFiles(n int64) {
+	if p == nil {
+		return
+	}
+	p.filesDone.Add(n)
+}
+
+// AddBytes records that n more bytes have been written.
+func (p *Progress) AddBytes(n int64) {
+	if p == nil {
+		return
+	}
+	p.bytesDone.Add(n)
+}
+
+// Handled records that the operation is done with path, one of the paths
+// it was given, either because it was carried out or because it was
+// skipped.
+func (p *Progress) Handled(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.handled == nil {
+		p.handled = make(map[string]bool)
+	}
+	p.handled[path] = true
+}
+
+// IsHandled reports whether Handled was called for path.
+func (p *Progress) IsHandled(path string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.handled[path]
+}
+
+// Stat returns a snapshot of the progress.
+func (p *Progress) Stat() ProgressStat {
+	if p == nil {
+		return ProgressStat{}
+	}
+	return ProgressStat{
+		FilesDone:  p.filesDone.Load(),
+		FilesTotal: p.filesTotal.Load(),
+		BytesDone:  p.bytesDone.Load(),
+		BytesTotal: p.bytesTotal.Load(),
+	}
+}
+
+// progressWriter counts the bytes written through it.
+type progressWriter struct {
+	w io.Writer
+	p *Progress
+}
+
+func (w progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.p.AddBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,154 @@
+package filesys
+
+import (
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxFinishedJobs is the number of finished jobs kept in a JobRegistry.
+// Failed jobs are kept until dismissed.
+const maxFinishedJobs = 50
+
+// JobState is the lifecycle state of a job.
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobFailed
+	JobDone
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobFailed:
+		return "failed"
+	case JobDone:
+		return "done"
+	}
+	return "unknown"
+}
+
+// JobRecord is what a JobRegistry knows about a job.
+type JobRecord struct {
+	JobInfo
+	State      JobState
+	Stat       ProgressStat
+	Err        error
+	Remaining  []string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Throughput returns the average number of bytes written per second.
+func (r JobRecord) Throughput() int64 {
+	if r.StartedAt.IsZero() {
+		return 0
+	}
+	end := r.FinishedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	elapsed := end.Sub(r.StartedAt).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(r.Stat.BytesDone) / elapsed)
+}
+
+// JobRegistry keeps track of the jobs submitted to Jobs by following the
+// messages they send.
+type JobRegistry struct {
+	records []*JobRecord // ordered by submission
+}
+
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{}
+}
+
+// Update applies a job message to the registry. It reports whether msg
+// was a job message.
+func (r *JobRegistry) Update(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case JobQueuedMsg:
+		r.records = append(r.records, &JobRecord{JobInfo: msg.Job, State: JobQueued})
+	case JobStartedMsg:
+		if rec := r.find(msg.ID); rec != nil {
+			rec.State = JobRunning
+			rec.StartedAt = time.Now()
+		}
+	case JobProgressMsg:
+		if rec := r.find(msg.ID); rec != nil {
+			rec.Stat = msg.Stat
+		}
+	case JobFinishedMsg:
+		rec := r.find(msg.ID)
+		if rec == nil {
+			return true
+		}
+		rec.FinishedAt = time.Now()
+		rec.Stat = msg.Stat
+		rec.Err = msg.Err
+		rec.Remaining = msg.Remaining
+		rec.State = JobDone
+		if msg.Err != nil {
+			rec.State = JobFailed
+		}
+		r.prune()
+	default:
+		return false
+	}
+	return true
+}
+
+// Records returns a snapshot of all jobs, oldest first.
+func (r *JobRegistry) Records() []JobRecord {
+	out := make([]JobRecord, len(r.records))
+	for i, rec := range r.records {
+		out[i] = *rec
+	}
+	return out
+}
+
+// Dismiss removes a failed or finished job. Queued and running jobs cannot
+// be dismissed.
+func (r *JobRegistry) Dismiss(id int) bool {
+	i := slices.IndexFunc(r.records, func(rec *JobRecord) bool { return rec.ID == id })
+	if i < 0 || r.records[i].State < JobFailed {
+		return false
+	}
+	r.records = slices.Delete(r.records, i, i+1)
+	return true
+}
+
+func (r *JobRegistry) find(id int) *JobRecord {
+	for _, rec := range r.records {
+		if rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+// prune drops the oldest finished jobs beyond maxFinishedJobs.
+func (r *JobRegistry) prune() {
+	done := 0
+	for _, rec := range r.records {
+		if rec.State == JobDone {
+			done++
+		}
+	}
+	r.records = slices.DeleteFunc(r.records, func(rec *JobRecord) bool {
+		if done > maxFinishedJobs && rec.State == JobDone {
+			done--
+			return true
+		}
+		return false
+	})
+}
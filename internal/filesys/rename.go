@@ -0,0 +1,116 @@
+package filesys
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Rename describes a single rename from one path to another.
+type Rename struct {
+	From string
+	To   string
+}
+
+// PlanRenames validates a bulk rename of sources to targets and returns the
+// steps needed to perform it. Swaps and cycles such as a->b, b->a are broken
+// up by moving one of the entries to a temporary name first.
+func PlanRenames(sources, targets []string) ([]Rename, error) {
+	if len(sources) != len(targets) {
+		return nil, fmt.Errorf("expected %d names, got %d", len(sources), len(targets))
+	}
+
+	pending := make(map[string]string, len(sources))
+	seen := make(map[string]string, len(targets))
+	for i, src := range sources {
+		src = filepath.Clean(src)
+		if strings.TrimSpace(targets[i]) == "" {
+			return nil, fmt.Errorf("empty name for %q", src)
+		}
+		dst := filepath.Clean(targets[i])
+		if other, ok := seen[dst]; ok {
+			return nil, fmt.Errorf("%q and %q would both be renamed to %q", other, src, dst)
+		}
+		seen[dst] = src
+		if dst != src {
+			pending[src] = dst
+		}
+	}
+
+	// Targets must either be free or be vacated by another rename
+	for _, dst := range pending {
+		if _, vacated := pending[dst]; vacated {
+			continue
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			return nil, fmt.Errorf("%q already exists", dst)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	steps := make([]Rename, 0, len(pending))
+	for len(pending) > 0 {
+		srcs := slices.Sorted(maps.Keys(pending))
+
+		progressed := false
+		for _, src := range srcs {
+			dst := pending[src]
+			if _, blocked := pending[dst]; blocked {
+				continue
+			}
+			steps = append(steps, Rename{From: src, To: dst})
+			delete(pending, src)
+			progressed = true
+		}
+		if progressed {
+			continue
+		}
+
+		// Only cycles remain, move one entry out of the way to break one up
+		src := srcs[0]
+		tmp, err := tempName(src)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, Rename{From: src, To: tmp})
+		pending[tmp] = pending[src]
+		delete(pending, src)
+	}
+
+	return steps, nil
+}
+
+// ApplyRenames performs the given steps in order. If a step fails, the steps
+// already performed are reverted.
+func ApplyRenames(steps []Rename) (err error) {
+	for i, step := range steps {
+		if err = os.Rename(step.From, step.To); err != nil {
+			for _, done := range slices.Backward(steps[:i]) {
+				if err2 := os.Rename(done.To, done.From); err2 != nil {
+					slog.Error("Failed to revert rename", "error", err2, "from", done.To, "to", done.From)
+				}
+			}
+			return err
+		}
+		slog.Info("Renamed", "path", step.From, "newPath", step.To)
+	}
+	return nil
+}
+
+// tempName returns an unused name in the same directory as path.
+func tempName(path string) (string, error) {
+	for range 100 {
+		tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".sail-rename-%08x", rand.Uint32()))
+		if _, err := os.Lstat(tmp); errors.Is(err, os.ErrNotExist) {
+			return tmp, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a temporary name for %q", path)
+}
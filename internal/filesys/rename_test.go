@@ -0,0 +1,73 @@
+package filesys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBulkRenameSwapAndChain(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("create %q: %v", name, err)
+		}
+	}
+
+	join := func(names ...string) []string {
+		out := make([]string, len(names))
+		for i, name := range names {
+			out[i] = filepath.Join(dir, name)
+		}
+		return out
+	}
+
+	// a<->b is a swap, c->d->e is a chain
+	steps, err := PlanRenames(join("a", "b", "c", "d"), join("b", "a", "d", "e"))
+	if err != nil {
+		t.Fatalf("PlanRenames failed: %v", err)
+	}
+	if err := ApplyRenames(steps); err != nil {
+		t.Fatalf("ApplyRenames failed: %v", err)
+	}
+
+	want := map[string]string{"a": "b", "b": "a", "d": "c", "e": "d"}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %q: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("%q: expected content %q, got %q", name, content, data)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), len(entries))
+	}
+}
+
+func TestPlanRenamesRejectsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("create %q: %v", name, err)
+		}
+	}
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+
+	tests := map[string]struct{ sources, targets []string }{
+		"line count": {[]string{a, b}, []string{a}},
+		"collision":  {[]string{a, b}, []string{c + "x", c + "x"}},
+		"existing":   {[]string{a}, []string{c}},
+	}
+	for name, tt := range tests {
+		if _, err := PlanRenames(tt.sources, tt.targets); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
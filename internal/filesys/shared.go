@@ -0,0 +1,273 @@
+package filesys
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sharedPollInterval is how often the state file is checked for changes
+// made by other instances.
+const sharedPollInterval = 250 * time.Millisecond
+
+// SharedStateMsg is sent when another instance changed the shared state.
+type SharedStateMsg struct {
+	data []byte
+}
+
+// sharedStateFile is the format of the state file.
+type sharedStateFile struct {
+	Selection []string `json:"selection"`
+	Clipboard struct {
+		Cut   bool     `json:"cut"`
+		Paths []string `json:"paths"`
+	} `json:"clipboard"`
+}
+
+// SharedState keeps a Selection and a Clipboard in sync with every other
+// running instance through a state file under $XDG_RUNTIME_DIR/sail.
+// Changes are written as they happen, and changes made by other instances
+// are picked up by polling the file.
+type SharedState struct {
+	path string
+	sel  *Selection
+	clip *Clipboard
+
+	mu   sync.Mutex
+	last []byte // contents last written or read
+}
+
+// OpenSharedState loads the shared state into sel and clip and starts
+// writing their changes to the state file.
+func OpenSharedState(sel *Selection, clip *Clipboard) (*SharedState, error) {
+	dir, err := sharedStateDir()
+	if err != nil {
+		return nil, err
+	}
+	s := &SharedState{path: filepath.Join(dir, "state.json"), sel: sel, clip: clip}
+
+	data, err := os.ReadFile(s.path)
+	if err == nil {
+		s.apply(data)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sel.onChange = s.save
+	clip.onChange = s.save
+	return s, nil
+}
+
+// sharedStateDir returns the directory holding the state file, creating it
+// if needed.
+func sharedStateDir() (string, error) {
+	if base := os.Getenv("XDG_RUNTIME_DIR"); base != "" {
+		dir := filepath.Join(base, "sail")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", err
+		}
+		return dir, nil
+	}
+
+	// Anyone can create this name in the temporary directory first, so it
+	// is only used if it turns out to be private to the user
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("sail-%d", os.Getuid()))
+	if err := os.Mkdir(dir, 0o700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// checkPrivateDir returns an error unless dir is a directory, and not a
+// symlink to one, that only the current user can access.
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	uid, ok := fileOwner(info)
+	switch {
+	case !info.IsDir():
+		return fmt.Errorf("%q is not a directory", dir)
+	case !ok || uid != os.Getuid():
+		return fmt.Errorf("%q is not owned by the current user", dir)
+	case info.Mode().Perm() != 0o700:
+		return fmt.Errorf("%q has mode %v, want 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// WatchCmd waits until another instance changes the state file. It must
+// be called again after each SharedStateMsg.
+func (s *SharedState) WatchCmd() tea.Cmd {
+	return func() tea.Msg {
+		var lastMod time.Time
+		for {
+			time.Sleep(sharedPollInterval)
+
+			info, err := os.Stat(s.path)
+			if err != nil || info.ModTime().Equal(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+
+			data, err := os.ReadFile(s.path)
+			if err != nil {
+				continue
+			}
+			s.mu.Lock()
+			changed := !bytes.Equal(data, s.last)
+			s.mu.Unlock()
+			if changed {
+				return SharedStateMsg{data: data}
+			}
+		}
+	}
+}
+
+// Apply loads the state carried by msg.
+func (s *SharedState) Apply(msg SharedStateMsg) {
+	s.apply(msg.data)
+}
+
+func (s *SharedState) apply(data []byte) {
+	var state sharedStateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		slog.Warn("Ignoring invalid shared state", "path", s.path, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.last = data
+	s.mu.Unlock()
+
+	s.sel.replace(state.Selection)
+	op := ClipCopy
+	if state.Clipboard.Cut {
+		op = ClipCut
+	}
+	s.clip.set(op, state.Clipboard.Paths)
+}
+
+// save writes the current state. The file is locked while it is updated,
+// and changes other instances made since it was last read are merged
+// rather than overwritten. The file is replaced atomically so other
+// instances never read a partial write.
+func (s *SharedState) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The state file itself is replaced on every write, so a separate file
+	// is locked
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		slog.Error("Failed to lock shared state", "path", s.path, "error", err)
+		return
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		slog.Error("Failed to read shared state", "path", s.path, "error", err)
+		return
+	}
+	state := s.merge(current)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		slog.Error("Failed to encode shared state", "error", err)
+		return
+	}
+	if bytes.Equal(data, current) {
+		s.last = data
+		return
+	}
+
+	if err := writeFileAtomic(s.path, data); err != nil {
+		slog.Error("Failed to write shared state", "path", s.path, "error", err)
+		return
+	}
+	s.last = data
+}
+
+// merge applies the changes made since the state was last read or written
+// to current, the state file as it is now, and loads the result. The local
+// clipboard replaces the other one only if it was changed.
+func (s *SharedState) merge(current []byte) sharedStateFile {
+	var base sharedStateFile
+	_ = json.Unmarshal(s.last, &base)
+	state := base
+	if current != nil && !bytes.Equal(current, s.last) {
+		var other sharedStateFile
+		if err := json.Unmarshal(current, &other); err != nil {
+			slog.Warn("Ignoring invalid shared state", "path", s.path, "error", err)
+		} else {
+			state = other
+		}
+	}
+
+	// Only the paths this instance selected or deselected since are applied,
+	// so the changes of the other instances are kept
+	selected := make(map[string]bool)
+	for _, path := range state.Selection {
+		selected[path] = true
+	}
+	inBase := make(map[string]bool, len(base.Selection))
+	for _, path := range base.Selection {
+		inBase[path] = true
+		if !s.sel.IsSelected(path) {
+			delete(selected, path)
+		}
+	}
+	for _, path := range s.sel.Paths() {
+		if !inBase[path] {
+			selected[path] = true
+		}
+	}
+	state.Selection = slices.Sorted(maps.Keys(selected))
+
+	op, paths := s.clip.Contents()
+	if (op == ClipCut) != base.Clipboard.Cut || !slices.Equal(paths, base.Clipboard.Paths) {
+		state.Clipboard.Cut = op == ClipCut
+		state.Clipboard.Paths = paths
+	}
+
+	s.sel.replace(state.Selection)
+	op = ClipCopy
+	if state.Clipboard.Cut {
+		op = ClipCut
+	}
+	s.clip.set(op, state.Clipboard.Paths)
+	return state
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	err = cmp.Or(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
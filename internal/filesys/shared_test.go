@@ -0,0 +1,144 @@
+package filesys
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSharedState(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	selA, clipA := NewSelection(), NewClipboard()
+	a, err := OpenSharedState(selA, clipA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selA.Select("/tmp/a")
+	clipA.Set(ClipCut, []string{"/tmp/b", "/tmp/c"})
+
+	// A new instance starts with the current state
+	selB, clipB := NewSelection(), NewClipboard()
+	if _, err := OpenSharedState(selB, clipB); err != nil {
+		t.Fatal(err)
+	}
+	if !selB.IsSelected("/tmp/a") {
+		t.Error("selection not loaded")
+	}
+	if op, paths := clipB.Contents(); op != ClipCut || !slices.Equal(paths, []string{"/tmp/b", "/tmp/c"}) {
+		t.Errorf("got clipboard %v %v", op, paths)
+	}
+
+	// Changes made by one instance reach the other
+	selB.Clear()
+	msg, ok := a.WatchCmd()().(SharedStateMsg)
+	if !ok {
+		t.Fatal("expected a SharedStateMsg")
+	}
+	a.Apply(msg)
+	if selA.Count() != 0 {
+		t.Errorf("selection not updated: %v", selA.Paths())
+	}
+	if clipA.Count() != 2 {
+		t.Errorf("clipboard changed unexpectedly: %d paths", clipA.Count())
+	}
+}
+
+func TestSharedStateMerge(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	selA, clipA := NewSelection(), NewClipboard()
+	if _, err := OpenSharedState(selA, clipA); err != nil {
+		t.Fatal(err)
+	}
+	selB, clipB := NewSelection(), NewClipboard()
+	if _, err := OpenSharedState(selB, clipB); err != nil {
+		t.Fatal(err)
+	}
+
+	// B writes before it has seen the changes made by A
+	selA.Select("/tmp/a")
+	clipA.Set(ClipCut, []string{"/tmp/c"})
+	selB.Select("/tmp/b")
+
+	if got := selB.Paths(); !slices.Equal(slices.Sorted(slices.Values(got)), []string{"/tmp/a", "/tmp/b"}) {
+		t.Errorf("got selection %v, want both instances' paths", got)
+	}
+	if op, paths := clipB.Contents(); op != ClipCut || !slices.Equal(paths, []string{"/tmp/c"}) {
+		t.Errorf("got clipboard %v %v, want the one set by the other instance", op, paths)
+	}
+
+	selC, clipC := NewSelection(), NewClipboard()
+	if _, err := OpenSharedState(selC, clipC); err != nil {
+		t.Fatal(err)
+	}
+	if selC.Count() != 2 || !selC.IsSelected("/tmp/a") || !selC.IsSelected("/tmp/b") {
+		t.Errorf("got saved selection %v", selC.Paths())
+	}
+}
+
+func TestSharedStateMergeDeselect(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	selA := NewSelection()
+	if _, err := OpenSharedState(selA, NewClipboard()); err != nil {
+		t.Fatal(err)
+	}
+	selA.Select("/tmp/x")
+
+	selB := NewSelection()
+	if _, err := OpenSharedState(selB, NewClipboard()); err != nil {
+		t.Fatal(err)
+	}
+
+	// B deselects what A still has selected, then A selects something else
+	// before it has seen that
+	selB.Deselect("/tmp/x")
+	selA.Select("/tmp/y")
+
+	want := []string{"/tmp/y"}
+	if got := slices.Sorted(slices.Values(selA.Paths())); !slices.Equal(got, want) {
+		t.Errorf("got selection %v, want %v", got, want)
+	}
+	selC := NewSelection()
+	if _, err := OpenSharedState(selC, NewClipboard()); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(slices.Values(selC.Paths())); !slices.Equal(got, want) {
+		t.Errorf("got saved selection %v, want %v", got, want)
+	}
+}
+
+func TestSharedStateDirMustBePrivate(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	dir := filepath.Join(tmp, fmt.Sprintf("sail-%d", os.Getuid()))
+
+	if got, err := sharedStateDir(); err != nil || got != dir {
+		t.Fatalf("sharedStateDir() = %q, %v, want %q", got, err, dir)
+	}
+
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sharedStateDir(); err == nil {
+		t.Error("expected a directory others can read to be refused")
+	}
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	target := t.TempDir()
+	if err := os.Chmod(target, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sharedStateDir(); err == nil {
+		t.Error("expected a symlink to be refused")
+	}
+}
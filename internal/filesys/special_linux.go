@@ -0,0 +1,21 @@
+//go:build linux
+
+package filesys
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// makeSpecial creates a FIFO or device node at dst like src, described by
+// info, the way cp -a does, instead of copying what reading src yields.
+func makeSpecial(src, dst string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("cannot copy special file %q", src)
+	}
+	return unix.Mknod(dst, st.Mode, int(st.Rdev))
+}
@@ -0,0 +1,58 @@
+package filesys
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopySpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.Mkdir(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "file"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Reading the pipe would block until something writes to it
+	if err := unix.Mkfifo(filepath.Join(src, "pipe"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("unix", filepath.Join(src, "sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, archive := range []bool{false, true} {
+		dst := filepath.Join(dir, "dst")
+		done := make(chan error, 1)
+		go func() {
+			done <- newCopier(context.Background(), nil, archive).copy(src, dst)
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("archive=%v: copy failed: %v", archive, err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("archive=%v: copy blocked on the pipe", archive)
+		}
+
+		info, err := os.Lstat(filepath.Join(dst, "pipe"))
+		if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+			t.Fatalf("archive=%v: pipe was not recreated: %v, %v", archive, info, err)
+		}
+		assertExists(t, filepath.Join(dst, "file"), true)
+		assertExists(t, filepath.Join(dst, "sock"), false)
+		if err := os.RemoveAll(dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package filesys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// makeSpecial creates a FIFO or device node at dst like src. This is only
+// supported on Linux.
+func makeSpecial(src, _ string, _ os.FileInfo) error {
+	return fmt.Errorf("cannot copy special file %q: %w", src, errors.ErrUnsupported)
+}
@@ -0,0 +1,29 @@
+package filesys
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// copyStrategy copies the contents of src, which is size bytes long, to the
+// empty file dst. It reports false if it cannot be used for these files, in
+// which case nothing has been written and the next strategy is tried.
+type copyStrategy func(ctx context.Context, dst, src *os.File, size int64, p *Progress) (bool, error)
+
+// copyContents copies src to dst with the first strategy that works for
+// them, falling back to reading and writing the data.
+func copyContents(ctx context.Context, dst, src *os.File, size int64, p *Progress) error {
+	for _, strategy := range copyStrategies {
+		ok, err := strategy(ctx, dst, src, size, p)
+		if ok || err != nil {
+			return err
+		}
+	}
+	return streamCopy(ctx, dst, src, p)
+}
+
+func streamCopy(ctx context.Context, dst, src *os.File, p *Progress) error {
+	_, err := io.Copy(progressWriter{w: dst, p: p}, ctxReader{ctx: ctx, r: src})
+	return err
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package filesys
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyRangeChunk is the most copy_file_range is asked to copy at once, so
+// that progress is reported and cancellation noticed.
+const copyRangeChunk = 8 << 20
+
+// copyStrategies lists the ways of copying file contents to try before
+// falling back to reading and writing the data.
+var copyStrategies = []copyStrategy{reflinkCopy, rangeCopy}
+
+// reflinkCopy makes dst share the data blocks of src, which is instant on
+// filesystems that support it, such as btrfs and XFS.
+func reflinkCopy(_ context.Context, dst, src *os.File, size int64, p *Progress) (bool, error) {
+	if err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())); err != nil {
+		return false, nil
+	}
+	p.AddBytes(size)
+	return true, nil
+}
+
+// rangeCopy copies the data regions of src with copy_file_range, leaving
+// holes in sparse files unallocated. Data is read and written instead if
+// the kernel cannot copy between the two files.
+func rangeCopy(ctx context.Context, dst, src *os.File, size int64, p *Progress) (bool, error) {
+	if info, err := src.Stat(); err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	extents, err := dataExtents(src, size)
+	if err != nil {
+		// Seeking moved the offset the next strategy reads from
+		_, err = src.Seek(0, io.SeekStart)
+		return false, err
+	}
+
+	kernelCopy := true
+	for _, ext := range extents {
+		start, end := ext[0], ext[1]
+		if kernelCopy {
+			n, err := copyFileRange(ctx, dst, src, start, end, p)
+			if err == nil {
+				continue
+			}
+			if !unsupported(err) {
+				return true, err
+			}
+			// Not available for these files, finish the extent by hand
+			kernelCopy = false
+			start += n
+		}
+		w := progressWriter{w: io.NewOffsetWriter(dst, start), p: p}
+		r := ctxReader{ctx: ctx, r: io.NewSectionReader(src, start, end-start)}
+		n, err := io.Copy(w, r)
+		if err != nil {
+			return true, err
+		}
+		if n < end-start {
+			return true, io.ErrUnexpectedEOF
+		}
+	}
+
+	// Holes count as done, and a trailing hole still needs the file to be
+	// extended to its full size
+	var copied int64
+	for _, ext := range extents {
+		copied += ext[1] - ext[0]
+	}
+	p.AddBytes(size - copied)
+	return true, dst.Truncate(size)
+}
+
+// copyFileRange copies the bytes in [start, end) of src to the same offset
+// in dst, returning how many were copied.
+func copyFileRange(ctx context.Context, dst, src *os.File, start, end int64, p *Progress) (int64, error) {
+	roff, woff := start, start
+	for roff < end {
+		if err := ctx.Err(); err != nil {
+			return roff - start, err
+		}
+		n, err := unix.CopyFileRange(int(src.Fd()), &roff, int(dst.Fd()), &woff, int(min(end-roff, copyRangeChunk)), 0)
+		if err != nil {
+			return roff - start, err
+		}
+		if n == 0 {
+			// The file shrank while it was being copied
+			return roff - start, io.ErrUnexpectedEOF
+		}
+		p.AddBytes(int64(n))
+	}
+	return roff - start, nil
+}
+
+// dataExtents returns the [start, end) offsets of the regions of f that
+// hold data. Filesystems without hole support report a single region.
+func dataExtents(f *os.File, size int64) ([][2]int64, error) {
+	fd := int(f.Fd())
+	var extents [][2]int64
+	for off := int64(0); off < size; {
+		start, err := unix.Seek(fd, off, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break // only a hole is left
+		}
+		if err != nil {
+			return nil, err
+		}
+		end, err := unix.Seek(fd, start, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		end = min(end, size)
+		if start >= end {
+			break
+		}
+		extents = append(extents, [2]int64{start, end})
+		off = end
+	}
+	return extents, nil
+}
+
+// unsupported reports whether copy_file_range failed because it cannot be
+// used for the files, rather than because of an I/O error.
+func unsupported(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.EBADF)
+}
@@ -0,0 +1,69 @@
+package filesys
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRangeCopyKeepsHoles(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src")
+	const size = 16 << 20
+
+	src, err := os.Create(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	for _, off := range []int64{0, 8 << 20} {
+		if _, err := src.WriteAt([]byte("data"), off); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := os.Create(filepath.Join(dir, "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	p := &Progress{}
+	if ok, err := rangeCopy(context.Background(), dst, src, size, p); !ok || err != nil {
+		t.Fatalf("rangeCopy = %v, %v", ok, err)
+	}
+	if got := p.Stat().BytesDone; got != size {
+		t.Errorf("got %d bytes of progress, want %d", got, size)
+	}
+
+	want, err := os.ReadFile(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("copy differs from source")
+	}
+
+	if allocated(t, srcPath) < size && allocated(t, dst.Name()) >= size {
+		t.Error("holes were filled in")
+	}
+}
+
+func allocated(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Sys().(*syscall.Stat_t).Blocks * 512
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package filesys
+
+// copyStrategies lists the ways of copying file contents to try before
+// falling back to reading and writing the data.
+var copyStrategies []copyStrategy
@@ -0,0 +1,444 @@
+package filesys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The trash follows the FreeDesktop.org trash specification:
+// https://specifications.freedesktop.org/trash-spec/latest/
+
+const (
+	trashInfoExt    = ".trashinfo"
+	trashTimeLayout = "2006-01-02T15:04:05"
+	trashInfoHeader = "[Trash Info]"
+	trashDirMode    = 0o700
+)
+
+// TrashPaths moves the given paths to the trash and returns the ops that
+// restore them.
+func TrashPaths(ctx context.Context, paths []string, p *Progress) ([]Op, error) {
+	ops := make([]Op, 0, len(paths))
+	for _, path := range unique(paths) {
+		if err := ctx.Err(); err != nil {
+			return ops, err
+		}
+		trashed, err := trashPath(path)
+		if err != nil {
+			return ops, err
+		}
+		ops = append(ops, Op{Kind: OpTrash, Src: path, Dst: trashed})
+		p.AddFiles(1)
+		p.Handled(path)
+	}
+	return ops, nil
+}
+
+// DeletePaths permanently deletes the given paths.
+func DeletePaths(ctx context.Context, paths []string, p *Progress) error {
+	for _, path := range unique(paths) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		slog.Info("Deleted", "path", path)
+		p.AddFiles(1)
+		p.Handled(path)
+	}
+	return nil
+}
+
+// trashPath moves path to the trash of the filesystem it lives on and
+// returns its location inside the trash.
+func trashPath(path string) (string, error) {
+	return trashPathAs(path, path)
+}
+
+// trashPathAs is trashPath for an entry that is to be restored to origPath
+// rather than to where it is now. Both must be on the same filesystem.
+func trashPathAs(path, origPath string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	origPath, err = filepath.Abs(origPath)
+	if err != nil {
+		return "", err
+	}
+
+	trashDir, topDir, err := trashDirFor(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trashDir, sub), trashDirMode); err != nil {
+			return "", err
+		}
+	}
+
+	infoPath, name, err := createTrashInfo(trashDir, topDir, origPath)
+	if err != nil {
+		return "", err
+	}
+
+	trashed := filepath.Join(trashDir, "files", name)
+	if err := os.Rename(path, trashed); err != nil {
+		if err2 := os.Remove(infoPath); err2 != nil {
+			slog.Error("Failed to remove trash info", "error", err2, "path", infoPath)
+		}
+		return "", err
+	}
+
+	slog.Info("Trashed", "path", origPath, "trashed", trashed)
+	return trashed, nil
+}
+
+// restoreTrashed moves a trashed entry back to path and removes its
+// metadata.
+func restoreTrashed(trashed, path string) error {
+	if err := os.Rename(trashed, path); err != nil {
+		return err
+	}
+	if err := os.Remove(trashInfoPath(trashed)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	slog.Info("Restored", "trashed", trashed, "path", path)
+	return nil
+}
+
+// trashInfoPath returns the .trashinfo file that belongs to a trashed entry.
+func trashInfoPath(trashed string) string {
+	trashDir := filepath.Dir(filepath.Dir(trashed))
+	return filepath.Join(trashDir, "info", filepath.Base(trashed)+trashInfoExt)
+}
+
+// createTrashInfo reserves a unique name in the trash by creating its
+// .trashinfo file. The recorded path is relative to topDir if set.
+func createTrashInfo(trashDir, topDir, path string) (infoPath, name string, err error) {
+	origPath := path
+	if topDir != "" {
+		if origPath, err = filepath.Rel(topDir, path); err != nil {
+			return "", "", err
+		}
+	}
+
+	content := fmt.Sprintf("%s\nPath=%s\nDeletionDate=%s\n",
+		trashInfoHeader,
+		(&url.URL{Path: origPath}).EscapedPath(),
+		time.Now().Format(trashTimeLayout),
+	)
+
+	base := filepath.Base(path)
+	for i := 1; ; i++ {
+		name = base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+
+		infoPath = filepath.Join(trashDir, "info", name+trashInfoExt)
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		// Skip names left behind in files/ without their metadata
+		if _, err := os.Lstat(filepath.Join(trashDir, "files", name)); err == nil {
+			f.Close()
+			_ = os.Remove(infoPath)
+			continue
+		}
+
+		_, err = f.WriteString(content)
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			_ = os.Remove(infoPath)
+			return "", "", err
+		}
+		return infoPath, name, nil
+	}
+}
+
+// homeTrashDir returns $XDG_DATA_HOME/Trash.
+func homeTrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashDirFor returns the trash directory to use for path. Paths on another
+// filesystem than the home trash go to a trash at the top of their mount,
+// in which case topDir is set to the mount point.
+func trashDirFor(path string) (trashDir, topDir string, err error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(home, trashDirMode); err != nil {
+		return "", "", err
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", "", err
+	}
+	dev, ok := deviceID(info)
+	if !ok {
+		return home, "", nil
+	}
+	homeInfo, err := os.Stat(home)
+	if err != nil {
+		return "", "", err
+	}
+	if homeDev, ok := deviceID(homeInfo); !ok || homeDev == dev {
+		return home, "", nil
+	}
+
+	topDir = mountPoint(path, dev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// An administrator provided $topdir/.Trash must be sticky and not a link
+	if info, err := os.Lstat(filepath.Join(topDir, ".Trash")); err == nil &&
+		info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(topDir, ".Trash", uid)
+		if err := os.MkdirAll(dir, trashDirMode); err == nil {
+			return dir, topDir, nil
+		}
+	}
+
+	dir := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.MkdirAll(dir, trashDirMode); err != nil {
+		return "", "", fmt.Errorf("cannot create trash on %q: %w", topDir, err)
+	}
+	if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("invalid trash directory %q", dir)
+	}
+	return dir, topDir, nil
+}
+
+// mountPoint walks up from path and returns the top-most directory that
+// still lives on device dev.
+func mountPoint(path string, dev uint64) string {
+	cur := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return cur
+		}
+		info, err := os.Lstat(parent)
+		if err != nil {
+			return cur
+		}
+		if pdev, ok := deviceID(info); !ok || pdev != dev {
+			return cur
+		}
+		cur = parent
+	}
+}
+
+// TrashEntry is an entry inside one of the trash directories.
+type TrashEntry struct {
+	// Path is the location of the entry inside the trash.
+	Path string
+	// OrigPath is the location the entry was deleted from.
+	OrigPath string
+	// DeletedAt is the time the entry was moved to the trash.
+	DeletedAt time.Time
+}
+
+// Name returns the original name of the entry.
+func (e TrashEntry) Name() string {
+	return filepath.Base(e.OrigPath)
+}
+
+// ListTrash returns the entries of every trash directory that is
+// accessible, most recently deleted first.
+func ListTrash() ([]TrashEntry, error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TrashEntry
+	for _, dir := range append([]string{home}, topTrashDirs()...) {
+		infos, err := os.ReadDir(filepath.Join(dir, "info"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		topDir := ""
+		if dir != home {
+			topDir = trashTopDir(dir)
+		}
+
+		for _, info := range infos {
+			name, ok := strings.CutSuffix(info.Name(), trashInfoExt)
+			if !ok {
+				continue
+			}
+			entry, err := readTrashInfo(filepath.Join(dir, "info", info.Name()), topDir)
+			if err != nil {
+				slog.Warn("Skipping invalid trash info", "error", err, "name", info.Name())
+				continue
+			}
+			entry.Path = filepath.Join(dir, "files", name)
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b TrashEntry) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return entries, nil
+}
+
+// RestoreTrash moves the given entries back to their original location. If
+// that location is taken, a "_restored" suffix is added to the name. It
+// returns the paths the entries were restored to.
+func RestoreTrash(entries []TrashEntry) ([]string, error) {
+	restored := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if _, err := mkdirAll(filepath.Dir(e.OrigPath)); err != nil {
+			return restored, err
+		}
+		target := freeName(e.OrigPath, "_restored")
+		if err := restoreTrashed(e.Path, target); err != nil {
+			return restored, err
+		}
+		restored = append(restored, target)
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently deletes the given entries from the trash.
+func PurgeTrash(entries []TrashEntry) error {
+	for _, e := range entries {
+		if err := os.RemoveAll(e.Path); err != nil {
+			return err
+		}
+		if err := os.Remove(trashInfoPath(e.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		slog.Info("Purged", "path", e.Path)
+	}
+	return nil
+}
+
+// readTrashInfo parses a .trashinfo file. Relative paths are resolved
+// against topDir.
+func readTrashInfo(path, topDir string) (TrashEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	var entry TrashEntry
+	inSection := false
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inSection = line == trashInfoHeader
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inSection || !ok {
+			continue
+		}
+
+		switch key {
+		case "Path":
+			p, err := url.PathUnescape(value)
+			if err != nil {
+				return TrashEntry{}, err
+			}
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(topDir, p)
+			}
+			entry.OrigPath = p
+		case "DeletionDate":
+			// The date is optional for our purposes, keep the entry if invalid
+			entry.DeletedAt, _ = time.ParseInLocation(trashTimeLayout, value, time.Local)
+		}
+	}
+
+	if entry.OrigPath == "" {
+		return TrashEntry{}, errors.New("missing Path key")
+	}
+	return entry, nil
+}
+
+// topTrashDirs returns the per-mount trash directories that exist.
+func topTrashDirs() []string {
+	data, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return nil
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+	var dirs []string
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		mount := unescapeMount(fields[1])
+		for _, dir := range []string{
+			filepath.Join(mount, ".Trash", uid),
+			filepath.Join(mount, ".Trash-"+uid),
+		} {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() && !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// trashTopDir returns the mount point a per-mount trash directory belongs to.
+func trashTopDir(trashDir string) string {
+	if parent := filepath.Dir(trashDir); filepath.Base(parent) == ".Trash" {
+		return filepath.Dir(parent)
+	}
+	return filepath.Dir(trashDir)
+}
+
+// unescapeMount decodes the octal escapes used in /proc/self/mounts.
+func unescapeMount(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package filesys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTrashPathsWritesTrashInfo(t *testing.T) {
+	dir := t.TempDir()
+	dataHome := filepath.Join(dir, "data")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	// Two files with the same name must not collide inside the trash
+	paths := []string{filepath.Join(dir, "a", "my file"), filepath.Join(dir, "b", "my file")}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ops, err := TrashPaths(context.Background(), paths, nil)
+	if err != nil {
+		t.Fatalf("TrashPaths failed: %v", err)
+	}
+
+	trashDir := filepath.Join(dataHome, "Trash")
+	want := []string{filepath.Join(trashDir, "files", "my file"), filepath.Join(trashDir, "files", "my file.2")}
+	for i, op := range ops {
+		if op.Dst != want[i] {
+			t.Errorf("expected %q to be trashed to %q, got %q", op.Src, want[i], op.Dst)
+		}
+		assertExists(t, op.Src, false)
+		assertExists(t, op.Dst, true)
+	}
+
+	info, err := os.ReadFile(filepath.Join(trashDir, "info", "my file.trashinfo"))
+	if err != nil {
+		t.Fatalf("read trash info: %v", err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\nPath="+filepath.Join(dir, "a", "my%20file")+"\nDeletionDate=") {
+		t.Errorf("unexpected trash info:\n%s", info)
+	}
+}
+
+func TestRestoreTrashHandlesCollisions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := TrashPaths(context.Background(), []string{path}, nil); err != nil {
+		t.Fatalf("TrashPaths failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := listTestTrash(dir)
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].OrigPath != path || entries[0].DeletedAt.IsZero() {
+		t.Fatalf("unexpected trash entries: %+v", entries)
+	}
+
+	restored, err := RestoreTrash(entries)
+	if err != nil {
+		t.Fatalf("RestoreTrash failed: %v", err)
+	}
+	want := filepath.Join(dir, "notes_restored.txt")
+	if len(restored) != 1 || restored[0] != want {
+		t.Fatalf("expected restore to %q, got %v", want, restored)
+	}
+	if data, _ := os.ReadFile(want); string(data) != "old" {
+		t.Errorf("restored content mismatch: %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("colliding file was modified: %q", data)
+	}
+
+	if entries, err := listTestTrash(dir); err != nil || len(entries) != 0 {
+		t.Errorf("expected empty trash, got %v (err %v)", entries, err)
+	}
+}
+
+// listTestTrash lists the trash entries that live inside dir, ignoring any
+// per-mount trash of the machine running the tests.
+func listTestTrash(dir string) ([]TrashEntry, error) {
+	entries, err := ListTrash()
+	return slices.DeleteFunc(entries, func(e TrashEntry) bool {
+		return !strings.HasPrefix(e.Path, dir)
+	}), err
+}
@@ -0,0 +1,141 @@
+// Package fuzzy implements fuzzy matching of short patterns against names
+// and paths, in the style of fzf and editor file pickers.
+package fuzzy
+
+import (
+	"cmp"
+	"slices"
+	"unicode"
+)
+
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 12
+	bonusBoundary    = 8
+	bonusCamel       = 6
+	bonusFirst       = 4
+)
+
+// Match reports whether the characters of pattern appear in str in order.
+// Positions holds the rune indexes of the matched characters in str and
+// score ranks the match, higher being better: matches at word boundaries
+// and runs of consecutive characters score higher, gaps score lower.
+//
+// Case is ignored unless pattern contains an upper case letter.
+func Match(pattern, str string) (score int, positions []int, ok bool) {
+	p, s := []rune(pattern), []rune(str)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	if len(p) > len(s) {
+		return 0, nil, false
+	}
+
+	eq := func(a, b rune) bool { return a == b }
+	if !hasUpper(p) {
+		eq = func(a, b rune) bool { return a == unicode.ToLower(b) }
+	}
+
+	// Find where the leftmost match ends, then walk back from there to the
+	// latest start, which gives the shortest span containing the match
+	end, pi := -1, 0
+	for i := 0; i < len(s) && pi < len(p); i++ {
+		if eq(p[pi], s[i]) {
+			pi++
+			end = i
+		}
+	}
+	if pi < len(p) {
+		return 0, nil, false
+	}
+	start, pi := end, len(p)-1
+	for ; start >= 0; start-- {
+		if eq(p[pi], s[start]) {
+			if pi == 0 {
+				break
+			}
+			pi--
+		}
+	}
+
+	positions = make([]int, 0, len(p))
+	pi = 0
+	for i := start; i <= end && pi < len(p); i++ {
+		if !eq(p[pi], s[i]) {
+			continue
+		}
+		score += scoreMatch + bonusAt(s, i)
+		if len(positions) > 0 {
+			prev := positions[len(positions)-1]
+			if prev == i-1 {
+				score += bonusConsecutive
+			} else {
+				score -= i - prev - 1
+			}
+		}
+		positions = append(positions, i)
+		pi++
+	}
+	return score, positions, true
+}
+
+// bonusAt returns the bonus for matching the rune at index i of s.
+func bonusAt(s []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary + bonusFirst
+	}
+	prev, curr := s[i-1], s[i]
+	switch {
+	case isSeparator(prev):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(curr),
+		!unicode.IsDigit(prev) && unicode.IsDigit(curr):
+		return bonusCamel
+	}
+	return 0
+}
+
+func isSeparator(r rune) bool {
+	switch r {
+	case '/', '\\', '_', '-', '.', ' ':
+		return true
+	}
+	return false
+}
+
+func hasUpper(runes []rune) bool {
+	return slices.ContainsFunc(runes, unicode.IsUpper)
+}
+
+// Result is a string that matched a pattern.
+type Result struct {
+	// Index is the position of the string in the slice passed to Filter.
+	Index     int
+	Score     int
+	Positions []int
+}
+
+// Filter returns the strings of strs matching pattern, best matches
+// first. Equal scores are ordered by length, then by their index.
+func Filter(pattern string, strs []string) []Result {
+	var results []Result
+	for i, str := range strs {
+		if score, positions, ok := Match(pattern, str); ok {
+			results = append(results, Result{Index: i, Score: score, Positions: positions})
+		}
+	}
+	Sort(results, strs)
+	return results
+}
+
+// Sort orders results the way Filter does. strs are the strings the
+// results index into.
+func Sort(results []Result, strs []string) {
+	slices.SortStableFunc(results, func(a, b Result) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(len(strs[a.Index]), len(strs[b.Index])),
+			cmp.Compare(a.Index, b.Index),
+		)
+	})
+}
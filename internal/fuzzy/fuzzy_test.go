@@ -0,0 +1,43 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, str string
+		want         []int
+		ok           bool
+	}{
+		{"", "anything", nil, true},
+		{"abc", "abc", []int{0, 1, 2}, true},
+		{"abc", "a_b_c", []int{0, 2, 4}, true},
+		{"abc", "acb", nil, false},
+		{"ABC", "abc", nil, false},
+		{"abc", "ABC", []int{0, 1, 2}, true},
+		// The shortest span is preferred over the leftmost one
+		{"ab", "a_xab", []int{3, 4}, true},
+		{"é", "café", []int{3}, true},
+	}
+	for _, tt := range tests {
+		_, got, ok := Match(tt.pattern, tt.str)
+		if ok != tt.ok || !slices.Equal(got, tt.want) {
+			t.Errorf("Match(%q, %q) = %v, %v; want %v, %v", tt.pattern, tt.str, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFilterRanking(t *testing.T) {
+	strs := []string{"a_very_long_readme", "src/readme.md", "README", "red"}
+
+	var got []string
+	for _, r := range Filter("readme", strs) {
+		got = append(got, strs[r.Index])
+	}
+	want := []string{"README", "src/readme.md", "a_very_long_readme"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Filter() = %q, want %q", got, want)
+	}
+}
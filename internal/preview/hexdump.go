@@ -0,0 +1,48 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hexdump renders the start of data like `hexdump -C`, with as many bytes
+// per line as fit in width, up to 16.
+func hexdump(data []byte, width, height int) []string {
+	// Each byte takes 3 columns as hex and 1 as text, besides the offset
+	// and the separators
+	perLine := min(16, max(4, (width-12)/4/4*4))
+	data = data[:min(len(data), perLine*height)]
+
+	offsetStyle := lipgloss.NewStyle().Foreground(theme.Overlay0)
+	hexStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	textStyle := lipgloss.NewStyle().Foreground(theme.Subtext0)
+
+	var lines []string
+	for off := 0; off < len(data); off += perLine {
+		chunk := data[off:min(len(data), off+perLine)]
+
+		var hex, text strings.Builder
+		for i := range perLine {
+			if i < len(chunk) {
+				fmt.Fprintf(&hex, "%02x ", chunk[i])
+				text.WriteByte(printable(chunk[i]))
+			} else {
+				hex.WriteString("   ")
+			}
+		}
+		lines = append(lines, offsetStyle.Render(fmt.Sprintf("%08x ", off))+
+			hexStyle.Render(hex.String())+
+			textStyle.Render("|"+text.String()+"|"))
+	}
+	return lines
+}
+
+func printable(b byte) byte {
+	if b < 0x20 || b > 0x7e {
+		return '.'
+	}
+	return b
+}
@@ -0,0 +1,312 @@
+package preview
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// language describes enough of the syntax of a language to highlight it.
+// Highlighting is a best effort meant to make a preview easier to scan: a
+// line is split into keywords, calls, numbers, strings and comments, with
+// no notion of the grammar beyond that. Keyword lists only hold the core
+// of each language.
+type language struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	// foldCase makes keywords match in any case. The keywords must then be
+	// given in lower case.
+	foldCase bool
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// cStyle returns a language with C comments and strings and the given
+// keywords.
+func cStyle(keywords string) *language {
+	return &language{
+		keywords:     words(keywords),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+	}
+}
+
+var (
+	goLang = cStyle(`break case chan const continue default defer else fallthrough for func go goto
+		if import interface map package range return select struct switch type var
+		true false nil iota any error string bool byte rune int int8 int16 int32 int64
+		uint uint8 uint16 uint32 uint64 uintptr float32 float64 complex64 complex128`)
+	cLang = cStyle(`auto bool break case catch char class const constexpr continue default delete
+		do double else enum extern false float for goto if inline int long namespace
+		new nullptr private protected public return short signed sizeof static struct
+		switch template this throw true try typedef union unsigned using virtual void
+		volatile while`)
+	javaLang = cStyle(`abstract boolean break byte case catch char class continue default do double
+		else enum extends false final finally float for if implements import instanceof
+		int interface long new null package private protected public return short static
+		super switch this throw throws true try var void while`)
+	csharpLang = cStyle(`abstract as async await bool break byte case catch char class const continue
+		decimal default delegate do double else enum false finally float for foreach if
+		in int interface internal is long namespace new null object out override private
+		protected public readonly ref return sealed static string struct switch this
+		throw true try using var virtual void while`)
+	kotlinLang = cStyle(`as break class continue data do else false for fun if import in interface is
+		null object override package private protected public return sealed super this
+		throw true try val var when while`)
+	jsLang = cStyle(`async await break case catch class const continue default delete do else export
+		extends false finally for from function if import in instanceof let new null of
+		return static super switch this throw true try typeof undefined var void while
+		yield interface type enum implements`)
+	rustLang = cStyle(`as async await break const continue crate else enum false fn for if impl in
+		let loop match mod move mut pub ref return self Self static struct super trait
+		true type unsafe use where while bool char str i8 i16 i32 i64 u8 u16 u32 u64
+		isize usize f32 f64 String Vec Option Result Some None Ok Err`)
+	swiftLang = cStyle(`as break case catch class continue default defer do else enum extension false
+		for func guard if import in init let nil private protocol public repeat return
+		self static struct super switch throw throws true try var where while`)
+	pythonLang = &language{
+		keywords: words(`and as assert async await break class continue def del elif else except
+			False finally for from global if import in is lambda None nonlocal not or pass
+			raise return True try while with yield self`),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	shellLang = &language{
+		keywords: words(`if then else elif fi case esac for while until do done in function
+			return local export readonly set unset shift exit echo source`),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	rubyLang = &language{
+		keywords: words(`alias and begin break case class def defined? do else elsif end ensure
+			false for if in module next nil not or redo rescue retry return self super
+			then true undef unless until when while yield require`),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	}
+	luaLang = &language{
+		keywords: words(`and break do else elseif end false for function goto if in local nil
+			not or repeat return then true until while`),
+		lineComments: []string{"--"},
+		quotes:       "\"'",
+	}
+	sqlLang = &language{
+		keywords: words(`select from where insert into values update set delete create table
+			drop alter index primary key foreign references not null and or join left
+			right inner outer on group by order having limit as distinct union`),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+		foldCase:     true,
+	}
+	configLang = &language{
+		keywords:     words(`true false yes no on off null`),
+		lineComments: []string{"#", ";"},
+		quotes:       "\"'",
+	}
+	jsonLang = &language{
+		keywords: words(`true false null`),
+		quotes:   "\"",
+	}
+)
+
+// languages maps file extensions, or names for files without one, to their
+// language.
+var languages = map[string]*language{
+	".go":    goLang,
+	".c":     cLang,
+	".h":     cLang,
+	".cc":    cLang,
+	".cpp":   cLang,
+	".hpp":   cLang,
+	".cs":    csharpLang,
+	".java":  javaLang,
+	".kt":    kotlinLang,
+	".js":    jsLang,
+	".jsx":   jsLang,
+	".mjs":   jsLang,
+	".ts":    jsLang,
+	".tsx":   jsLang,
+	".rs":    rustLang,
+	".swift": swiftLang,
+	".py":    pythonLang,
+	".sh":    shellLang,
+	".bash":  shellLang,
+	".zsh":   shellLang,
+	".rb":    rubyLang,
+	".lua":   luaLang,
+	".sql":   sqlLang,
+	".yaml":  configLang,
+	".yml":   configLang,
+	".toml":  configLang,
+	".ini":   configLang,
+	".conf":  configLang,
+	".json":  jsonLang,
+
+	"Makefile":   shellLang,
+	"Dockerfile": shellLang,
+	".bashrc":    shellLang,
+	".zshrc":     shellLang,
+	".profile":   shellLang,
+}
+
+var (
+	keywordStyle = lipgloss.NewStyle().Foreground(theme.Mauve)
+	stringStyle  = lipgloss.NewStyle().Foreground(theme.Green)
+	commentStyle = lipgloss.NewStyle().Foreground(theme.Overlay1).Italic(true)
+	numberStyle  = lipgloss.NewStyle().Foreground(theme.Peach)
+	callStyle    = lipgloss.NewStyle().Foreground(theme.Blue)
+	textStyle    = lipgloss.NewStyle().Foreground(theme.Text)
+)
+
+// highlight styles the lines of the file called name. Files in a language
+// that is not known are only given the text color.
+func highlight(name string, lines []string) []string {
+	lang, ok := languages[filepath.Ext(name)]
+	if !ok {
+		lang, ok = languages[name]
+	}
+
+	out := make([]string, len(lines))
+	inComment := false
+	for i, line := range lines {
+		if !ok {
+			out[i] = textStyle.Render(line)
+			continue
+		}
+		out[i], inComment = lang.highlightLine(line, inComment)
+	}
+	return out
+}
+
+// highlightLine styles a single line. inComment tells whether the line
+// starts inside a block comment, and the result whether the next one does.
+func (l *language) highlightLine(line string, inComment bool) (string, bool) {
+	var sb strings.Builder
+	plain := 0 // start of the text not styled yet
+	flush := func(i int) {
+		if i > plain {
+			sb.WriteString(textStyle.Render(line[plain:i]))
+		}
+	}
+	emit := func(i, j int, style lipgloss.Style) {
+		flush(i)
+		sb.WriteString(style.Render(line[i:j]))
+		plain = j
+	}
+
+	i := 0
+	if inComment {
+		end := strings.Index(line, l.blockComment[1])
+		if end < 0 {
+			return commentStyle.Render(line), true
+		}
+		i = end + len(l.blockComment[1])
+		emit(0, i, commentStyle)
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+		if l.startsLineComment(rest) {
+			emit(i, len(line), commentStyle)
+			return sb.String(), false
+		}
+		if start := l.blockComment[0]; start != "" && strings.HasPrefix(rest, start) {
+			end := strings.Index(rest[len(start):], l.blockComment[1])
+			if end < 0 {
+				emit(i, len(line), commentStyle)
+				return sb.String(), true
+			}
+			j := i + len(start) + end + len(l.blockComment[1])
+			emit(i, j, commentStyle)
+			i = j
+			continue
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(l.quotes, c) >= 0:
+			j := stringEnd(line, i)
+			emit(i, j, stringStyle)
+			i = j
+		case isDigit(c) && (i == 0 || !isWord(line[i-1])):
+			j := i
+			for j < len(line) && (isWord(line[j]) || line[j] == '.') {
+				j++
+			}
+			emit(i, j, numberStyle)
+			i = j
+		case isWordStart(c):
+			j := i
+			for j < len(line) && isWord(line[j]) {
+				j++
+			}
+			switch {
+			case l.isKeyword(line[i:j]):
+				emit(i, j, keywordStyle)
+			case j < len(line) && line[j] == '(':
+				emit(i, j, callStyle)
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	flush(len(line))
+	return sb.String(), false
+}
+
+func (l *language) isKeyword(word string) bool {
+	if l.foldCase {
+		word = strings.ToLower(word)
+	}
+	return l.keywords[word]
+}
+
+func (l *language) startsLineComment(s string) bool {
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringEnd returns the index after the string literal starting at i.
+// Unterminated strings run to the end of the line.
+func stringEnd(line string, i int) int {
+	quote := line[i]
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return len(line)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
+
+func isWord(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
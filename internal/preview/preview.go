@@ -0,0 +1,118 @@
+// Package preview renders the contents of files for the child pane.
+package preview
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alx99/sail/internal/util"
+)
+
+const (
+	// maxBytes is the most that is read of a file.
+	maxBytes = 1 << 20
+	// tabWidth is the number of spaces a tab is expanded to.
+	tabWidth = 4
+)
+
+// Preview is the rendered start of a file.
+type Preview struct {
+	Path string
+	// Lines are the lines to show, already styled.
+	Lines []string
+	// Numbered is set if the lines should be shown with line numbers,
+	// which is the case for text.
+	Numbered bool
+	// Truncated is set if the file is larger than what was read of it.
+	Truncated bool
+	Size      int64
+}
+
+// LoadedMsg carries the result of Previewer.LoadCmd.
+type LoadedMsg struct {
+	ReqID   int
+	Preview Preview
+}
+
+// LoadFailedMsg is sent by Previewer.LoadCmd if the file could not be
+// previewed.
+type LoadFailedMsg struct {
+	ReqID int
+	Err   error
+}
+
+// Load renders the start of the file at path, as many lines as fit in
+// height. Text is highlighted according to the file name, binary files are
+// shown as a hexdump.
+func Load(path string, width, height int) (Preview, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Preview{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		return Preview{}, err
+	}
+
+	p := Preview{
+		Path:      path,
+		Size:      info.Size(),
+		Truncated: info.Size() > int64(len(data)),
+	}
+	if util.IsBinary(data) {
+		p.Lines = hexdump(data, width, height)
+		return p, nil
+	}
+
+	p.Lines = highlight(filepath.Base(path), splitLines(data, height))
+	p.Numbered = true
+	return p, nil
+}
+
+// splitLines returns the first n lines of data, with tabs expanded and
+// control characters replaced so that they cannot mess up the terminal.
+func splitLines(data []byte, n int) []string {
+	var lines []string
+	for len(data) > 0 && len(lines) < n {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		lines = append(lines, sanitize(string(line)))
+	}
+	return lines
+}
+
+func sanitize(line string) string {
+	var sb strings.Builder
+	writeText(&sb, strings.TrimSuffix(line, "\r"), 0)
+	return sb.String()
+}
+
+// writeText writes s to sb with tabs expanded and control characters
+// replaced. col is the column s starts at, and the column after it is
+// returned.
+func writeText(sb *strings.Builder, s string, col int) int {
+	for _, r := range s {
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(util.SafeRune(r))
+		col++
+	}
+	return col
+}
@@ -0,0 +1,127 @@
+package preview
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func strip(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = ansi.Strip(line)
+	}
+	return out
+}
+
+func TestLoadText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\r\n\nfunc main() {\n\tprintln(\"\x1b[31mhi\")\n}\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path, 80, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"package main", "", "func main() {", `    println("?[31mhi")`}
+	if got := strip(p.Lines); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	if !p.Numbered || p.Truncated {
+		t.Fatalf("Numbered = %v, Truncated = %v; want true, false", p.Numbered, p.Truncated)
+	}
+}
+
+func TestLoadBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob")
+	data := append([]byte("ELF\x00"), bytes.Repeat([]byte{0xff}, 40)...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path, 80, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"00000000 45 4c 46 00 ff ff ff ff ff ff ff ff ff ff ff ff |ELF.............|",
+		"00000010 ff ff ff ff ff ff ff ff ff ff ff ff ff ff ff ff |................|",
+	}
+	if got := strip(p.Lines); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	if p.Numbered {
+		t.Fatal("hexdump is numbered")
+	}
+
+	// Narrow panes get fewer bytes per line
+	for _, line := range strip(hexdump(data, 40, 1)) {
+		if len(line) > 40 {
+			t.Fatalf("line %q is wider than 40", line)
+		}
+	}
+}
+
+func TestLoadTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), maxBytes+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path, 80, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Truncated || p.Size != maxBytes+1 || len(p.Lines) != 1 {
+		t.Fatalf("Truncated = %v, Size = %d, %d lines; want true, %d, 1", p.Truncated, p.Size, len(p.Lines), maxBytes+1)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	lines := []string{
+		`x := "a // b" // comment`,
+		`/* start`,
+		`end */ return 42`,
+		`call(0x1f)`,
+	}
+	got := highlight("x.go", lines)
+	if !slices.Equal(strip(got), lines) {
+		t.Fatalf("highlighting changed the text: %q", strip(got))
+	}
+
+	// The comment marker in the string is ignored, and block comments
+	// carry over to the next line
+	tests := []struct {
+		line      string
+		inComment bool
+		want      bool
+	}{
+		{`s := "/*"`, false, false},
+		{`/* start`, false, true},
+		{`still inside`, true, true},
+		{`end */ x /* again`, true, true},
+		{`end */ // /*`, true, false},
+	}
+	for _, tt := range tests {
+		if _, got := goLang.highlightLine(tt.line, tt.inComment); got != tt.want {
+			t.Errorf("highlightLine(%q, %v) ends in comment = %v, want %v", tt.line, tt.inComment, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightFoldCase(t *testing.T) {
+	for _, word := range []string{"select", "SELECT", "Select"} {
+		if !sqlLang.isKeyword(word) {
+			t.Errorf("%q is not a SQL keyword", word)
+		}
+	}
+	if goLang.isKeyword("FUNC") {
+		t.Error("Go keywords should be case sensitive")
+	}
+}
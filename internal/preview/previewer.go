@@ -0,0 +1,234 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// cacheSize is the number of previews kept.
+	cacheSize = 64
+	// waitDelay is how long the output of a killed previewer is waited
+	// for, in case it started processes of its own that hold on to it.
+	waitDelay = 100 * time.Millisecond
+)
+
+// cacheKey identifies the output of a previewer. The same file is
+// previewed again when it changes or the pane is resized.
+type cacheKey struct {
+	path          string
+	mtime         int64
+	size          int64
+	width, height int
+}
+
+// Previewer loads previews, through an external command if one is
+// configured. Like lf's previewer, the command is called with the path of
+// the file and the width and height of the pane, and whatever it prints is
+// shown, including colors. If it exits with a non-zero status or times out,
+// the built-in preview is shown instead. Successful output is cached until
+// the file changes.
+type Previewer struct {
+	command string
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[cacheKey]Preview
+	order []cacheKey // oldest first
+}
+
+// NewPreviewer returns a Previewer running command, or using only the
+// built-in previews if it is empty. The command is stopped once it has run
+// for timeout.
+func NewPreviewer(command string, timeout time.Duration) *Previewer {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(command, "~/") {
+		command = filepath.Join(home, command[2:])
+	}
+	return &Previewer{
+		command: command,
+		timeout: timeout,
+		cache:   make(map[cacheKey]Preview),
+	}
+}
+
+// LoadCmd renders the preview of the file at path for a pane of the given
+// size. Cancelling ctx stops the previewer command, in which case no
+// message is returned.
+func (pv *Previewer) LoadCmd(ctx context.Context, reqID int, path string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		p, err := pv.Load(ctx, path, width, height)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return LoadFailedMsg{ReqID: reqID, Err: err}
+		}
+		return LoadedMsg{ReqID: reqID, Preview: p}
+	}
+}
+
+// Load renders the preview of the file at path, see Previewer.
+func (pv *Previewer) Load(ctx context.Context, path string, width, height int) (Preview, error) {
+	if pv.command == "" {
+		return Load(path, width, height)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Preview{}, err
+	}
+	key := cacheKey{path: path, mtime: info.ModTime().UnixNano(), size: info.Size(), width: width, height: height}
+	if p, ok := pv.cached(key); ok {
+		return p, nil
+	}
+
+	p, err := pv.run(ctx, path, width, height)
+	if ctx.Err() != nil {
+		return Preview{}, ctx.Err()
+	}
+	if err != nil {
+		slog.Warn("Previewer failed, using the built-in preview", "error", err, "path", path)
+		return Load(path, width, height)
+	}
+	p.Size = info.Size()
+	pv.store(key, p)
+	return p, nil
+}
+
+// run runs the previewer command for path and returns its output.
+func (pv *Previewer) run(ctx context.Context, path string, width, height int) (Preview, error) {
+	ctx, cancel := context.WithTimeout(ctx, pv.timeout)
+	defer cancel()
+
+	var out limitedBuffer
+	out.limit = maxBytes
+	cmd := exec.CommandContext(ctx, pv.command, path, strconv.Itoa(width), strconv.Itoa(height))
+	cmd.Stdout = &out
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return Preview{}, fmt.Errorf("%s timed out after %s", pv.command, pv.timeout)
+	}
+	if err != nil {
+		return Preview{}, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if out.Len() == 0 {
+		lines = nil
+	}
+	lines = lines[:min(len(lines), height)]
+	for i, line := range lines {
+		lines[i] = sanitizeANSI(line)
+	}
+	return Preview{Path: path, Lines: lines, Truncated: out.truncated}, nil
+}
+
+func (pv *Previewer) cached(key cacheKey) (Preview, bool) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	p, ok := pv.cache[key]
+	return p, ok
+}
+
+// store caches p under key, evicting the oldest preview if the cache is
+// full.
+func (pv *Previewer) store(key cacheKey, p Preview) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+
+	if _, ok := pv.cache[key]; ok {
+		return
+	}
+	if len(pv.order) >= cacheSize {
+		delete(pv.cache, pv.order[0])
+		pv.order = pv.order[1:]
+	}
+	pv.cache[key] = p
+	pv.order = append(pv.order, key)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards
+// the rest, so that a chatty previewer is not blocked on a full pipe.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(0, room)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// sanitizeANSI keeps the colors and text attributes of line, but drops any
+// other escape sequence, since cursor movements and the like would mess up
+// the screen. Tabs are expanded and other control characters replaced.
+func sanitizeANSI(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+
+	var sb strings.Builder
+	col, styled := 0, false
+	for i := 0; i < len(line); i++ {
+		if line[i] != 0x1b {
+			end := strings.IndexByte(line[i:], 0x1b)
+			if end < 0 {
+				end = len(line) - i
+			}
+			col = writeText(&sb, line[i:i+end], col)
+			i += end - 1
+			continue
+		}
+		if i+1 >= len(line) {
+			break
+		}
+
+		switch line[i+1] {
+		case '[':
+			// CSI: parameters and intermediates up to a final byte
+			j := i + 2
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+			if j < len(line) && line[j] == 'm' {
+				sb.WriteString(line[i : j+1])
+				styled = true
+			}
+			i = j
+		case ']':
+			// OSC: up to BEL or ST
+			j := i + 2
+			for j < len(line) && line[j] != 0x07 && !strings.HasPrefix(line[j:], "\x1b\\") {
+				j++
+			}
+			if strings.HasPrefix(line[j:], "\x1b\\") {
+				j++
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	if styled {
+		// Keep the styles from leaking into the next line
+		sb.WriteString("\x1b[m")
+	}
+	return sb.String()
+}
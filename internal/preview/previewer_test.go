@@ -0,0 +1,133 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "previewer")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPreviewer(t *testing.T) {
+	count := filepath.Join(t.TempDir(), "count")
+	script := writeScript(t, `echo run >> "`+count+`"
+printf '\033[31m%s\033[2J\n' "$1 $2 $3"
+echo second
+echo third
+`)
+	path := writeFile(t, "hello\n")
+	pv := NewPreviewer(script, time.Second)
+
+	p, err := pv.Load(context.Background(), path, 40, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"\x1b[31m" + path + " 40 2\x1b[m", "second"}
+	if !slices.Equal(p.Lines, want) {
+		t.Fatalf("lines = %q, want %q", p.Lines, want)
+	}
+	if p.Numbered {
+		t.Fatal("previewer output should not be numbered")
+	}
+
+	// The output is cached until the file changes
+	if _, err := pv.Load(context.Background(), path, 40, 2); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pv.Load(context.Background(), path, 40, 2); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := os.ReadFile(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(runs); got != "run\nrun\n" {
+		t.Fatalf("previewer ran %q, want twice", got)
+	}
+}
+
+func TestPreviewerFallback(t *testing.T) {
+	path := writeFile(t, "hello\n")
+	for name, body := range map[string]string{
+		"failure": "echo ignored\nexit 1\n",
+		"timeout": "exec sleep 5\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			pv := NewPreviewer(writeScript(t, body), 50*time.Millisecond)
+			p, err := pv.Load(context.Background(), path, 40, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strip(p.Lines); !slices.Equal(got, []string{"hello"}) || !p.Numbered {
+				t.Fatalf("lines = %q, want the built-in preview", got)
+			}
+		})
+	}
+}
+
+func TestPreviewerCancel(t *testing.T) {
+	pv := NewPreviewer(writeScript(t, "exec sleep 5\n"), 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	msg := pv.LoadCmd(ctx, 1, writeFile(t, "hello\n"), 40, 5)()
+	if msg != nil {
+		t.Fatalf("msg = %v, want none", msg)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("previewer kept running for %s after being cancelled", elapsed)
+	}
+}
+
+func TestPreviewerLoadFailed(t *testing.T) {
+	pv := NewPreviewer("", time.Second)
+	missing := filepath.Join(t.TempDir(), "missing")
+	msg, ok := pv.LoadCmd(context.Background(), 3, missing, 40, 5)().(LoadFailedMsg)
+	if !ok || msg.ReqID != 3 || !errors.Is(msg.Err, fs.ErrNotExist) {
+		t.Fatalf("msg = %#v, want a LoadFailedMsg for request 3", msg)
+	}
+}
+
+func TestSanitizeANSI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain\ttext\r", "plain   text"},
+		{"\x1b[1;32mgreen\x1b[0m", "\x1b[1;32mgreen\x1b[0m\x1b[m"},
+		{"a\x1b[2Kb\x1b[10;5Hc", "abc"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x07", "link"},
+		{"bell\x07", "bell?"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeANSI(tt.in); got != tt.want {
+			t.Errorf("sanitizeANSI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
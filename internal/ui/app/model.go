@@ -1,13 +1,17 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	"github.com/alx99/sail/internal/config"
 	"github.com/alx99/sail/internal/filesys"
 	"github.com/alx99/sail/internal/style"
 	"github.com/alx99/sail/internal/ui/browser"
+	"github.com/alx99/sail/internal/ui/components/joblist"
 	"github.com/alx99/sail/internal/ui/components/status"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,32 +22,58 @@ type Model struct {
 	browser   *browser.Model
 	status    *status.View
 	selection *filesys.Selection
+	clipboard *filesys.Clipboard
+	shared    *filesys.SharedState
+	jobs      *filesys.Jobs
 	altScreen bool
 	printLast string
+
+	registry  *filesys.JobRegistry
+	jobPanel  *joblist.View
+	panelOpen bool
+
+	width  int
+	height int
 }
 
 func New(cwd string, cfg config.Config, styles *style.Styles) *Model {
 	selection := filesys.NewSelection()
+	clipboard := filesys.NewClipboard()
+	shared, err := filesys.OpenSharedState(selection, clipboard)
+	if err != nil {
+		slog.Error("Failed to share selection with other instances", "error", err)
+	}
+	journal := filesys.NewJournal()
+	jobs := filesys.NewJobs(journal)
 	return &Model{
 		cfg:       cfg,
-		browser:   browser.New(cwd, cfg, styles, selection),
+		browser:   browser.New(cwd, cfg, styles, selection, clipboard, journal, jobs),
 		status:    status.New(),
 		selection: selection,
+		clipboard: clipboard,
+		shared:    shared,
+		jobs:      jobs,
+		registry:  filesys.NewJobRegistry(),
+		jobPanel:  joblist.New(),
 		altScreen: cfg.Settings.AltScreen,
 		printLast: cfg.PrintLastWD,
 	}
 }
 
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(m.browser.Init(), m.status.Init())
+	cmds := []tea.Cmd{m.browser.Init(), m.status.Init(), m.jobs.Listen()}
+	if m.shared != nil {
+		cmds = append(cmds, m.shared.WatchCmd())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
-	// Handle global keys
-	if msg, ok := msg.(tea.KeyMsg); ok {
+	// Handle global keys, unless the browser is capturing input
+	if msg, ok := msg.(tea.KeyMsg); ok && (!m.browser.Capturing() || msg.String() == "ctrl+c") {
 		switch msg.String() {
 		case "ctrl+c", "q":
 			if m.printLast != "" {
@@ -58,6 +88,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.EnterAltScreen
 			}
 			return m, tea.ExitAltScreen
+		case m.cfg.Settings.Keymap.JobPanel:
+			m.panelOpen = !m.panelOpen
+			return m, m.resize()
+		}
+		if m.panelOpen {
+			return m, m.updatePanel(msg)
 		}
 	}
 
@@ -69,17 +105,23 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle specific messages for status bar coordination
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 		m.status.SetWidth(msg.Width)
-
-		// adjusted height accounting for status bar
-		adjHeight := max(msg.Height-m.status.Height(), 0)
-
-		m.browser, cmd = m.browser.Update(tea.WindowSizeMsg{Width: msg.Width, Height: adjHeight})
-		cmds = append(cmds, cmd)
+		m.jobPanel.SetWidth(msg.Width)
+		cmds = append(cmds, m.resize())
 
 		return m, tea.Batch(cmds...)
 	case filesys.DirLoadedMsg:
 		cmds = append(cmds, m.status.SetWD(msg.Dir))
+	case filesys.JobQueuedMsg, filesys.JobStartedMsg, filesys.JobProgressMsg, filesys.JobFinishedMsg:
+		m.registry.Update(msg)
+		m.jobPanel.SetRecords(m.registry.Records())
+		cmds = append(cmds, m.jobs.Listen())
+	case filesys.SharedStateMsg:
+		m.shared.Apply(msg)
+		cmds = append(cmds, m.shared.WatchCmd())
+	case filesys.FilesComparedMsg:
+		cmds = append(cmds, m.status.SetInfo(describeComparison(msg.Comparison)))
 	case error:
 		slog.Error("Error occurred", "error", msg)
 		cmds = append(cmds, m.status.SetError(msg))
@@ -92,9 +134,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if err != nil {
 		cmds = append(cmds, errorCmd(err))
 	} else {
+		clipOp, _ := m.clipboard.Contents()
 		m.status.SetSelection(status.Stats{
 			Stats:          stats,
 			SelectionCount: m.selection.Count(),
+			ClipOp:         clipOp,
+			ClipCount:      m.clipboard.Count(),
 		})
 	}
 
@@ -102,12 +147,73 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
+	bottom := m.status.View()
+	if p, ok := m.browser.PromptView(); ok {
+		bottom = p
+	}
+
+	if m.panelOpen {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			m.browser.View(),
+			m.jobPanel.View(),
+			bottom,
+		)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left,
 		m.browser.View(),
-		m.status.View(),
+		bottom,
 	)
 }
 
+// resize gives the browser whatever height is left by the status bar and
+// the job panel.
+func (m *Model) resize() tea.Cmd {
+	adjHeight := m.height - m.status.Height()
+	if m.panelOpen {
+		adjHeight -= m.jobPanel.Height()
+	}
+
+	var cmd tea.Cmd
+	m.browser, cmd = m.browser.Update(tea.WindowSizeMsg{Width: m.width, Height: max(adjHeight, 0)})
+	return cmd
+}
+
+// updatePanel handles a key press while the job panel has focus.
+func (m *Model) updatePanel(msg tea.KeyMsg) tea.Cmd {
+	km := m.cfg.Settings.Keymap
+	switch msg.String() {
+	case "esc":
+		m.panelOpen = false
+		return m.resize()
+	case km.NavUp:
+		m.jobPanel.MoveUp()
+	case km.NavDown:
+		m.jobPanel.MoveDown()
+	case km.CancelJob:
+		if !m.jobs.Cancel() {
+			return errorCmd(errors.New("no running job to cancel"))
+		}
+	case km.JobRetry:
+		rec, ok := m.jobPanel.CurrRecord()
+		if !ok || rec.State != filesys.JobFailed {
+			return errorCmd(errors.New("only failed jobs can be retried"))
+		}
+		if len(rec.Remaining) == 0 {
+			return errorCmd(errors.New("nothing left to retry"))
+		}
+		m.registry.Dismiss(rec.ID)
+		m.jobPanel.SetRecords(m.registry.Records())
+		return m.jobs.RetryCmd(rec.JobInfo, rec.Remaining)
+	case km.JobDismiss:
+		rec, ok := m.jobPanel.CurrRecord()
+		if !ok || !m.registry.Dismiss(rec.ID) {
+			return errorCmd(errors.New("only finished jobs can be dismissed"))
+		}
+		m.jobPanel.SetRecords(m.registry.Records())
+	}
+	return nil
+}
+
 func (m *Model) writeLastWD() error {
 	f, err := os.Create(m.printLast)
 	if err != nil {
@@ -118,6 +224,21 @@ func (m *Model) writeLastWD() error {
 	return err
 }
 
+// describeComparison summarizes the result of comparing files.
+func describeComparison(c filesys.Comparison) string {
+	if c.Identical() {
+		digest := c.Digests[0]
+		if len(digest) > 12 {
+			digest = digest[:12] + "…"
+		}
+		return fmt.Sprintf("%d files are identical (%s %s)", len(c.Paths), c.Algo, digest)
+	}
+	if len(c.Paths) == 2 {
+		return fmt.Sprintf("%s and %s differ", filepath.Base(c.Paths[0]), filepath.Base(c.Paths[1]))
+	}
+	return fmt.Sprintf("%d files differ, %d distinct contents", len(c.Paths), c.Distinct())
+}
+
 func errorCmd(err error) tea.Cmd {
 	if err == nil {
 		return nil
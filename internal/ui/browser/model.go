@@ -9,15 +9,27 @@ package browser
 // however, this is quite an edge case, so I will leave it as is for now.
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/alx99/sail/internal/collator"
 	"github.com/alx99/sail/internal/config"
 	"github.com/alx99/sail/internal/filesys"
+	"github.com/alx99/sail/internal/preview"
 	"github.com/alx99/sail/internal/style"
+	"github.com/alx99/sail/internal/ui/components/confirm"
+	"github.com/alx99/sail/internal/ui/components/conflict"
 	"github.com/alx99/sail/internal/ui/components/filelist"
+	"github.com/alx99/sail/internal/ui/components/filepreview"
+	"github.com/alx99/sail/internal/ui/components/finder"
+	"github.com/alx99/sail/internal/ui/components/greplist"
+	"github.com/alx99/sail/internal/ui/components/prompt"
+	"github.com/alx99/sail/internal/ui/components/trashlist"
 	"github.com/alx99/sail/internal/ui/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -28,6 +40,9 @@ type Model struct {
 
 	cwd       string // current working directory
 	selection *filesys.Selection
+	clipboard *filesys.Clipboard
+	journal   *filesys.Journal
+	jobs      *filesys.Jobs
 
 	termCols int // max width of the terminal window
 	termRows int // max height of the terminal window
@@ -37,11 +52,43 @@ type Model struct {
 	parentEnabled bool
 	showHidden    bool
 
+	// filePreview is shown in the child pane when the cursor is on a file
+	filePreview   *filepreview.View
+	previewing    bool
+	previewer     *preview.Previewer
+	cancelPreview context.CancelFunc
+
+	prompt   *prompt.View
+	onSubmit func(value string) tea.Cmd
+	// onChange and onCancel are set for prompts that act as the user types
+	onChange func(value string) tea.Cmd
+	onCancel func()
+
+	trash     *trashlist.View
+	trashMode bool
+
+	conflict   *conflict.View
+	onResolved func(map[string]filesys.Resolution) tea.Cmd
+
+	finder    *finder.View
+	finderJob *filesys.Finder
+	finderID  int
+
+	grep     *greplist.View
+	grepJob  *filesys.Grep
+	grepID   int
+	grepMode bool
+
+	confirm      *confirm.View
+	onConfirm    tea.Cmd
+	confirmReqID int
+	cancelSize   context.CancelFunc
+
 	wdReqID    int
 	childReqID int
 }
 
-func New(cwd string, cfg config.Config, styles *style.Styles, selection *filesys.Selection) *Model {
+func New(cwd string, cfg config.Config, styles *style.Styles, selection *filesys.Selection, clipboard *filesys.Clipboard, journal *filesys.Journal, jobs *filesys.Jobs) *Model {
 	parentDir := filepath.Dir(cwd)
 	coll := collator.New()
 	v := &Model{
@@ -51,6 +98,17 @@ func New(cwd string, cfg config.Config, styles *style.Styles, selection *filesys
 		cwd:           cwd,
 		cfg:           cfg,
 		selection:     selection,
+		clipboard:     clipboard,
+		journal:       journal,
+		jobs:          jobs,
+		prompt:        prompt.New(),
+		trash:         trashlist.New(),
+		confirm:       confirm.New(),
+		conflict:      conflict.New(),
+		finder:        finder.New(),
+		grep:          greplist.New(),
+		filePreview:   filepreview.New(),
+		previewer:     preview.NewPreviewer(cfg.Settings.Previewer, cfg.Settings.PreviewerTimeout),
 		parentEnabled: true,
 		showHidden:    false,
 	}
@@ -65,6 +123,25 @@ func (v *Model) Init() tea.Cmd {
 func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if v.confirm.Active() {
+			return v, v.updateConfirm(msg)
+		}
+		if v.conflict.Active() {
+			return v, v.updateConflict(msg)
+		}
+		if v.prompt.Active() {
+			return v, v.updatePrompt(msg)
+		}
+		if v.finder.Active() {
+			return v, v.updateFinder(msg)
+		}
+		if v.trashMode {
+			return v, v.updateTrash(msg)
+		}
+		if v.grepMode {
+			return v, v.updateGrep(msg)
+		}
+
 		switch msg.String() {
 		case v.cfg.Settings.Keymap.NavUp:
 			v.wd.MoveUp()
@@ -109,21 +186,146 @@ func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 			if len(paths) == 0 {
 				return v, nil
 			}
-			return v, filesys.DeleteCmd(paths)
+			if v.cfg.Settings.UseTrash {
+				return v, v.confirmCmd(v.cfg.Settings.Confirm.Trash || v.outsideWD(paths), "Move to trash?", paths,
+					v.jobs.TrashCmd(paths))
+			}
+			return v, v.confirmCmd(v.cfg.Settings.Confirm.Delete || v.outsideWD(paths), "Delete permanently?", paths,
+				v.jobs.DeleteCmd(paths))
 
-		case v.cfg.Settings.Keymap.Cut:
+		case v.cfg.Settings.Keymap.DeletePermanent:
 			paths := v.selection.Paths()
 			if len(paths) == 0 {
 				return v, nil
 			}
-			return v, filesys.MoveCmd(paths, v.cwd)
+			return v, v.confirmCmd(v.cfg.Settings.Confirm.Delete || v.outsideWD(paths), "Delete permanently?", paths,
+				v.jobs.DeleteCmd(paths))
+
+		case v.cfg.Settings.Keymap.Cut:
+			v.yank(filesys.ClipCut)
+			return v, nil
 
 		case v.cfg.Settings.Keymap.Copy:
+			v.yank(filesys.ClipCopy)
+			return v, nil
+
+		case v.cfg.Settings.Keymap.Paste:
+			op, paths := v.clipboard.Contents()
+			if len(paths) == 0 {
+				return v, errorCmd(errors.New("clipboard is empty"))
+			}
+			dst := v.cwd
+			if op == filesys.ClipCut {
+				return v, v.pasteCmd(filesys.MoveConflicts(paths, dst), func(opts filesys.PasteOptions) tea.Cmd {
+					return v.jobs.MoveCmd(paths, dst, opts)
+				})
+			}
+			return v, v.pasteCmd(filesys.CopyConflicts(paths, dst), func(opts filesys.PasteOptions) tea.Cmd {
+				return v.jobs.CopyCmd(paths, dst, opts)
+			})
+
+		case v.cfg.Settings.Keymap.Symlink, v.cfg.Settings.Keymap.SymlinkRelative, v.cfg.Settings.Keymap.Hardlink:
+			// Link to what was yanked, or to the selection if nothing was
+			_, paths := v.clipboard.Contents()
+			if len(paths) == 0 {
+				paths = v.selection.Paths()
+			}
+			if len(paths) == 0 {
+				return v, nil
+			}
+			kind := filesys.SymlinkAbsolute
+			switch msg.String() {
+			case v.cfg.Settings.Keymap.SymlinkRelative:
+				kind = filesys.SymlinkRelative
+			case v.cfg.Settings.Keymap.Hardlink:
+				kind = filesys.Hardlink
+			}
+			dst := v.cwd
+			return v, v.pasteCmd(filesys.LinkConflicts(paths, dst), func(opts filesys.PasteOptions) tea.Cmd {
+				return v.jobs.LinkCmd(paths, dst, kind, opts)
+			})
+
+		case v.cfg.Settings.Keymap.Rename:
+			e, ok := v.wd.CurrEntry()
+			if !ok {
+				return v, nil
+			}
+
+			path := e.Path()
+			v.openPrompt("Rename", e.Name(), func(name string) tea.Cmd {
+				if name == e.Name() {
+					return nil
+				}
+				return filesys.RenameCmd(v.journal, path, name)
+			})
+			// Place the cursor before the extension to ease renaming the stem
+			if ext := filepath.Ext(e.Name()); ext != "" && ext != e.Name() {
+				v.prompt.SetCursor(len([]rune(e.Name())) - len([]rune(ext)))
+			}
+			return v, nil
+
+		case v.cfg.Settings.Keymap.BulkRename:
 			paths := v.selection.Paths()
 			if len(paths) == 0 {
 				return v, nil
 			}
-			return v, filesys.CopyCmd(paths, v.cwd)
+			slices.Sort(paths)
+			return v, filesys.EditNamesCmd(v.cwd, paths)
+
+		case v.cfg.Settings.Keymap.CreateFile:
+			cwd := v.cwd
+			v.openPrompt("New file", "", func(name string) tea.Cmd {
+				if name == "" {
+					return nil
+				}
+				// A trailing slash creates a directory instead
+				if strings.HasSuffix(name, "/") {
+					return filesys.MkdirCmd(v.journal, filepath.Join(cwd, name))
+				}
+				return filesys.CreateFileCmd(v.journal, filepath.Join(cwd, name))
+			})
+			return v, nil
+
+		case v.cfg.Settings.Keymap.Mkdir:
+			cwd := v.cwd
+			v.openPrompt("New directory", "", func(name string) tea.Cmd {
+				if name == "" {
+					return nil
+				}
+				return filesys.MkdirCmd(v.journal, filepath.Join(cwd, name))
+			})
+			return v, nil
+
+		case v.cfg.Settings.Keymap.TrashView:
+			// Entries left from the last visit may be gone, and their names
+			// reused by newer ones
+			v.trash.Clear()
+			v.trashMode = true
+			return v, filesys.ListTrashCmd()
+
+		case v.cfg.Settings.Keymap.Undo:
+			return v, filesys.UndoCmd(v.journal)
+
+		case v.cfg.Settings.Keymap.Redo:
+			return v, filesys.RedoCmd(v.journal)
+
+		case v.cfg.Settings.Keymap.Compare:
+			algo, err := filesys.ParseHashAlgo(v.cfg.Settings.VerifyCopy)
+			if err != nil {
+				return v, errorCmd(err)
+			}
+			if algo == filesys.HashNone {
+				algo = filesys.HashSHA256
+			}
+			paths := v.selection.Paths()
+			slices.Sort(paths)
+			return v, v.jobs.CompareCmd(algo, paths)
+
+		case v.cfg.Settings.Keymap.CancelJob:
+			if !v.jobs.Cancel() {
+				return v, errorCmd(errors.New("no running job to cancel"))
+			}
+			return v, nil
 
 		case v.cfg.Settings.Keymap.Select:
 			e, ok := v.wd.CurrEntry()
@@ -138,6 +340,75 @@ func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 
 			return v, nil
 
+		case v.cfg.Settings.Keymap.RangeSelect:
+			if v.wd.Ranging() {
+				v.selection.SelectPaths(v.wd.EndRange())
+			} else {
+				v.wd.StartRange()
+			}
+			return v, nil
+
+		case "esc":
+			v.wd.CancelRange()
+			v.wd.ClearSearch()
+			return v, nil
+
+		case v.cfg.Settings.Keymap.Search:
+			v.wd.StartSearch()
+			v.openPrompt("Search", "", func(string) tea.Cmd { return nil })
+			v.onChange = func(query string) tea.Cmd {
+				v.wd.Search(query)
+				return v.loadChildDir()
+			}
+			v.onCancel = v.wd.CancelSearch
+			return v, nil
+
+		case v.cfg.Settings.Keymap.Find:
+			v.finderID++
+			v.finderJob = filesys.StartFinder(v.finderID, v.cwd, v.showHidden)
+			v.finder.Open(v.cwd)
+			return v, v.finderJob.Next()
+
+		case v.cfg.Settings.Keymap.Grep:
+			v.openPrompt("Grep", "", v.startGrep)
+			return v, nil
+
+		case v.cfg.Settings.Keymap.SearchNext, v.cfg.Settings.Keymap.SearchPrev:
+			query := v.wd.SearchQuery()
+			if query == "" {
+				return v, nil
+			}
+			var found bool
+			if msg.String() == v.cfg.Settings.Keymap.SearchNext {
+				found = v.wd.NextMatch()
+			} else {
+				found = v.wd.PrevMatch()
+			}
+			if !found {
+				return v, errorCmd(fmt.Errorf("no match for %q", query))
+			}
+			return v, v.loadChildDir()
+
+		case v.cfg.Settings.Keymap.SelectAll:
+			v.selection.SelectPaths(v.wd.VisiblePaths())
+			return v, nil
+
+		case v.cfg.Settings.Keymap.InvertSelection:
+			v.selection.Invert(v.wd.VisiblePaths())
+			return v, nil
+
+		case v.cfg.Settings.Keymap.SelectPattern:
+			v.openPrompt("Select", "", func(pattern string) tea.Cmd {
+				return v.selectPattern(pattern, v.selection.SelectPaths)
+			})
+			return v, nil
+
+		case v.cfg.Settings.Keymap.DeselectPattern:
+			v.openPrompt("Deselect", "", func(pattern string) tea.Cmd {
+				return v.selectPattern(pattern, v.selection.DeselectPaths)
+			})
+			return v, nil
+
 		case v.cfg.Settings.Keymap.ToggleParentPane:
 			v.parentEnabled = !v.parentEnabled
 			v.updateLayout()
@@ -160,11 +431,100 @@ func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		v.termCols = msg.Width
 		v.termRows = msg.Height
 
+		v.prompt.SetWidth(msg.Width)
 		v.updateLayout()
 
+		// Previews only cover the size of the pane they were loaded for
+		if v.previewing {
+			return v, v.loadChildDir()
+		}
 		return v, nil
 
-	case filesys.FilesDeletedMsg, filesys.FilesMovedMsg, filesys.FilesCopiedMsg:
+	case filesys.FileRenamedMsg:
+		if v.selection.IsSelected(msg.OldPath) {
+			v.selection.Deselect(msg.OldPath)
+			v.selection.Select(msg.NewPath)
+		}
+		if filepath.Dir(msg.NewPath) != v.cwd {
+			return v, nil
+		}
+		return v, v.loadDirWithSelection(v.cwd, filepath.Base(msg.NewPath))
+
+	case filesys.NamesEditedMsg:
+		return v, filesys.BulkRenameCmd(v.journal, msg.Sources, msg.Targets)
+
+	case filesys.FilesRenamedMsg:
+		v.selection.Clear()
+		selectName := ""
+		if e, ok := v.wd.CurrEntry(); ok {
+			selectName = e.Name()
+			for _, r := range msg.Renames {
+				if r.From == e.Path() && filepath.Dir(r.To) == v.cwd {
+					selectName = filepath.Base(r.To)
+				}
+			}
+		}
+		return v, v.loadDirWithSelection(v.cwd, selectName)
+
+	case filesys.FileCreatedMsg:
+		// Put the cursor on the top-most entry that was created in the cwd
+		rel, err := filepath.Rel(v.cwd, msg.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return v, nil
+		}
+		return v, v.loadDirWithSelection(v.cwd, strings.Split(rel, string(filepath.Separator))[0])
+
+	case filesys.SizeMsg:
+		if msg.ReqID == v.confirmReqID && v.confirm.Active() {
+			v.confirm.SetSize(msg.Size)
+		}
+		return v, nil
+
+	case filesys.FinderMsg:
+		if msg.ID != v.finderID || !v.finder.Active() {
+			return v, nil
+		}
+		v.finder.Append(msg.Paths)
+		if msg.Done {
+			v.finder.SetDone()
+			return v, nil
+		}
+		return v, v.finderJob.Next()
+
+	case filesys.GrepMsg:
+		if msg.ID != v.grepID || !v.grepMode {
+			return v, nil
+		}
+		v.grep.Append(msg.Matches)
+		if msg.Done {
+			v.grep.SetDone(msg.Truncated)
+			return v, nil
+		}
+		return v, v.grepJob.Next()
+
+	case filesys.TrashListedMsg:
+		v.trash.SetEntries(msg.Entries)
+		return v, nil
+
+	case filesys.TrashRestoredMsg:
+		return v, tea.Batch(filesys.ListTrashCmd(), v.reloadDir())
+
+	case filesys.TrashPurgedMsg:
+		return v, filesys.ListTrashCmd()
+
+	case filesys.UndoneMsg, filesys.RedoneMsg:
+		v.selection.Clear()
+		return v, v.reloadDir()
+
+	case filesys.FilesMovedMsg:
+		// Cut paths can only be pasted once
+		if op, paths := v.clipboard.Contents(); op == filesys.ClipCut && slices.Equal(paths, msg.Paths) {
+			v.clipboard.Clear()
+		}
+		v.selection.Clear()
+		return v, v.loadDir(v.cwd)
+
+	case filesys.FilesDeletedMsg, filesys.FilesCopiedMsg, filesys.FilesLinkedMsg:
 		v.selection.Clear()
 		return v, v.loadDir(v.cwd)
 
@@ -208,6 +568,21 @@ func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		v.childEnabled = true
 
 		return v, nil
+
+	case preview.LoadedMsg:
+		if msg.ReqID != v.childReqID {
+			return v, nil
+		}
+		v.filePreview.SetPreview(msg.Preview)
+		v.previewing = true
+		return v, nil
+
+	case preview.LoadFailedMsg:
+		// The file may not exist anymore if the cursor has moved on
+		if msg.ReqID != v.childReqID {
+			return v, nil
+		}
+		return v, errorCmd(msg.Err)
 	case error:
 		// Let the parent handle status updates for errors.
 		return v, nil
@@ -218,6 +593,22 @@ func (v *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 }
 
 func (v *Model) View() string {
+	if v.confirm.Active() {
+		return v.confirm.View()
+	}
+	if v.conflict.Active() {
+		return v.conflict.View()
+	}
+	if v.finder.Active() {
+		return v.finder.View()
+	}
+	if v.trashMode {
+		return v.fullPaneStyle().Render(v.trash.View())
+	}
+	if v.grepMode {
+		return v.fullPaneStyle().Render(v.grep.View())
+	}
+
 	parentW, currentW, childW := v.calculatePaneWidths(v.termCols)
 	paneHeight := v.getFileHeight()
 
@@ -228,6 +619,8 @@ func (v *Model) View() string {
 	var childView string
 	if v.childEnabled {
 		childView = dStyle.Render(v.cd.View())
+	} else if v.previewing {
+		childView = dStyle.Render(v.filePreview.View())
 	} else {
 		childView = dStyle.Render("")
 	}
@@ -337,10 +730,310 @@ func (v *Model) heightDeduction() int {
 	return 2
 }
 
+// Capturing reports whether the browser consumes all key presses, for
+// example while a prompt is open.
+func (v *Model) Capturing() bool {
+	return v.prompt.Active() || v.confirm.Active() || v.conflict.Active() || v.finder.Active()
+}
+
+// PromptView renders the open prompt, if any.
+func (v *Model) PromptView() (string, bool) {
+	if !v.prompt.Active() {
+		return "", false
+	}
+	return v.prompt.View(), true
+}
+
+func (v *Model) openPrompt(label, value string, onSubmit func(value string) tea.Cmd) {
+	v.prompt.Open(label, value)
+	v.onSubmit = onSubmit
+}
+
+func (v *Model) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	prev := v.prompt.Value()
+	switch v.prompt.Update(msg) {
+	case prompt.Submit:
+		value, onSubmit := v.prompt.Value(), v.onSubmit
+		v.closePrompt()
+		return onSubmit(value)
+	case prompt.Cancel:
+		onCancel := v.onCancel
+		v.closePrompt()
+		if onCancel != nil {
+			onCancel()
+			return v.loadChildDir()
+		}
+	case prompt.None:
+		if value := v.prompt.Value(); value != prev && v.onChange != nil {
+			return v.onChange(value)
+		}
+	}
+	return nil
+}
+
+func (v *Model) closePrompt() {
+	v.prompt.Close()
+	v.onSubmit = nil
+	v.onChange = nil
+	v.onCancel = nil
+}
+
+// selectPattern passes the listed entries matching pattern to apply.
+func (v *Model) selectPattern(pattern string, apply func([]string)) tea.Cmd {
+	if pattern == "" {
+		return nil
+	}
+	paths, err := v.wd.MatchPaths(pattern)
+	if err != nil {
+		return errorCmd(err)
+	}
+	apply(paths)
+	return nil
+}
+
 func (v *Model) CWD() string {
 	return v.cwd
 }
 
+// reloadDir reloads the current directory, keeping the cursor on the current
+// entry.
+func (v *Model) reloadDir() tea.Cmd {
+	selectName := ""
+	if e, ok := v.wd.CurrEntry(); ok {
+		selectName = e.Name()
+	}
+	return v.loadDirWithSelection(v.cwd, selectName)
+}
+
+// updateFinder handles a key press while the finder is open. Picking an
+// entry opens its directory with the cursor on it.
+func (v *Model) updateFinder(msg tea.KeyMsg) tea.Cmd {
+	switch v.finder.Update(msg) {
+	case finder.Pick:
+		path, ok := v.finder.Picked()
+		v.closeFinder()
+		if ok {
+			return v.loadDirWithSelection(filepath.Dir(path), filepath.Base(path))
+		}
+	case finder.Cancel:
+		v.closeFinder()
+	}
+	return nil
+}
+
+func (v *Model) closeFinder() {
+	v.finder.Close()
+	if v.finderJob != nil {
+		v.finderJob.Stop()
+		v.finderJob = nil
+	}
+}
+
+func (v *Model) updateTrash(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case v.cfg.Settings.Keymap.NavUp:
+		v.trash.MoveUp()
+	case v.cfg.Settings.Keymap.NavDown:
+		v.trash.MoveDown()
+	case v.cfg.Settings.Keymap.Select:
+		v.trash.ToggleMark()
+		v.trash.MoveDown()
+	case v.cfg.Settings.Keymap.TrashRestore:
+		if targets := v.trash.Targets(); len(targets) > 0 {
+			return filesys.RestoreTrashCmd(targets)
+		}
+	case v.cfg.Settings.Keymap.TrashPurge:
+		if targets := v.trash.Targets(); len(targets) > 0 {
+			return v.confirmCmd(v.cfg.Settings.Confirm.Delete, "Delete permanently?", trashPaths(targets),
+				filesys.PurgeTrashCmd(targets))
+		}
+	case v.cfg.Settings.Keymap.TrashEmpty:
+		if entries := v.trash.Entries(); len(entries) > 0 {
+			return v.confirmCmd(v.cfg.Settings.Confirm.Delete, "Empty the trash?", trashPaths(entries),
+				filesys.PurgeTrashCmd(entries))
+		}
+	case v.cfg.Settings.Keymap.TrashView, v.cfg.Settings.Keymap.NavLeft, "esc":
+		v.trashMode = false
+	}
+	return nil
+}
+
+// startGrep searches the contents of the files below the cwd for pattern
+// and lists the matches in place of the panes.
+func (v *Model) startGrep(pattern string) tea.Cmd {
+	if pattern == "" {
+		return nil
+	}
+	v.stopGrep()
+	v.grepID++
+	job, err := filesys.StartGrep(v.grepID, v.cwd, pattern, v.showHidden)
+	if err != nil {
+		return errorCmd(err)
+	}
+	v.grepJob = job
+	v.grep.Reset(v.cwd, pattern)
+	v.grepMode = true
+	return job.Next()
+}
+
+func (v *Model) stopGrep() {
+	if v.grepJob != nil {
+		v.grepJob.Stop()
+		v.grepJob = nil
+	}
+}
+
+// updateGrep handles a key press while the matches of a content search are
+// listed. Opening a match navigates to its file.
+func (v *Model) updateGrep(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case v.cfg.Settings.Keymap.NavUp:
+		v.grep.MoveUp()
+	case v.cfg.Settings.Keymap.NavDown:
+		v.grep.MoveDown()
+	case v.cfg.Settings.Keymap.NavRight, "enter":
+		m, ok := v.grep.CurrMatch()
+		if !ok {
+			return nil
+		}
+		v.grepMode = false
+		v.stopGrep()
+		return v.loadDirWithSelection(filepath.Dir(m.Path), filepath.Base(m.Path))
+	case v.cfg.Settings.Keymap.Grep, v.cfg.Settings.Keymap.NavLeft, "esc":
+		v.grepMode = false
+		v.stopGrep()
+	}
+	return nil
+}
+
+func trashPaths(entries []filesys.TrashEntry) []string {
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return paths
+}
+
+// outsideWD reports whether any of paths is not in the working directory.
+// The selection is shared with other instances, so it can hold paths the
+// user cannot see, and removing those is always confirmed.
+func (v *Model) outsideWD(paths []string) bool {
+	return slices.ContainsFunc(paths, func(path string) bool {
+		return filepath.Dir(path) != v.wd.Path()
+	})
+}
+
+// confirmCmd returns cmd if the operation does not need to be confirmed.
+// Otherwise it opens a dialog listing the affected paths and holds cmd back
+// until the user answers.
+func (v *Model) confirmCmd(needed bool, title string, paths []string, cmd tea.Cmd) tea.Cmd {
+	if !needed {
+		return cmd
+	}
+
+	slices.Sort(paths)
+	v.confirm.Open(title, paths)
+	v.onConfirm = cmd
+	v.confirmReqID++
+
+	var ctx context.Context
+	ctx, v.cancelSize = context.WithCancel(context.Background())
+	return filesys.SizeCmd(ctx, v.confirmReqID, paths)
+}
+
+func (v *Model) updateConfirm(msg tea.KeyMsg) tea.Cmd {
+	result := v.confirm.Update(msg)
+	if result == confirm.None {
+		return nil
+	}
+
+	cmd := v.onConfirm
+	v.confirm.Close()
+	v.onConfirm = nil
+	if v.cancelSize != nil {
+		v.cancelSize()
+		v.cancelSize = nil
+	}
+
+	if result == confirm.Yes {
+		return cmd
+	}
+	return nil
+}
+
+// yank puts the selection, or the entry under the cursor if nothing is
+// selected, into the clipboard. The selection is cleared so that it can be
+// used for something else before pasting.
+func (v *Model) yank(op filesys.ClipOp) {
+	paths := v.selection.Paths()
+	if len(paths) == 0 {
+		e, ok := v.wd.CurrEntry()
+		if !ok {
+			return
+		}
+		paths = []string{e.Path()}
+	}
+	v.clipboard.Set(op, paths)
+	v.selection.Clear()
+}
+
+// pasteCmd asks the user how to resolve each conflict of a move or copy
+// before running it, and confirms overwrites if configured.
+func (v *Model) pasteCmd(conflicts []filesys.Conflict, run func(filesys.PasteOptions) tea.Cmd) tea.Cmd {
+	verify, err := filesys.ParseHashAlgo(v.cfg.Settings.VerifyCopy)
+	if err != nil {
+		return errorCmd(err)
+	}
+	opts := filesys.PasteOptions{
+		TrashReplaced: v.cfg.Settings.UseTrash,
+		Archive:       v.cfg.Settings.ArchiveCopy,
+		Verify:        verify,
+	}
+	if len(conflicts) == 0 {
+		return run(opts)
+	}
+
+	v.conflict.Open(conflicts)
+	v.onResolved = func(resolutions map[string]filesys.Resolution) tea.Cmd {
+		opts.Resolutions = resolutions
+
+		var replaced []string
+		for _, c := range conflicts {
+			if resolutions[c.Src] == filesys.Overwrite {
+				replaced = append(replaced, c.Dst)
+			}
+		}
+		return v.confirmCmd(v.cfg.Settings.Confirm.Overwrite && len(replaced) > 0, "Overwrite existing files?", replaced,
+			run(opts))
+	}
+	return nil
+}
+
+func (v *Model) updateConflict(msg tea.KeyMsg) tea.Cmd {
+	switch v.conflict.Update(msg) {
+	case conflict.Done:
+		onResolved, resolutions := v.onResolved, v.conflict.Resolutions()
+		v.conflict.Close()
+		v.onResolved = nil
+		return onResolved(resolutions)
+	case conflict.Cancel:
+		v.conflict.Close()
+		v.onResolved = nil
+	}
+	return nil
+}
+
+// fullPaneStyle is the style of lists that take the place of all panes,
+// like the trash.
+func (v *Model) fullPaneStyle() lipgloss.Style {
+	width := max(0, v.termCols-v.borderDeduction(1))
+	height := v.getFileHeight()
+	if v.cfg.Settings.MinimalUI {
+		return lipgloss.NewStyle().Width(width).Height(height)
+	}
+	return theme.DefaultTheme.ActiveBorder.Width(width).Height(height)
+}
+
 func (v *Model) loadDir(path string) tea.Cmd {
 	return v.loadDirWithSelection(path, "")
 }
@@ -351,6 +1044,8 @@ func (v *Model) loadDirWithSelection(path, selectName string) tea.Cmd {
 }
 
 func (v *Model) loadChildDir() tea.Cmd {
+	v.previewing = false
+	v.stopPreview()
 	e, ok := v.wd.CurrEntry()
 	if !ok {
 		return nil
@@ -364,7 +1059,10 @@ func (v *Model) loadChildDir() tea.Cmd {
 
 	if !resolved.IsDir() {
 		v.childEnabled = false
-		return nil
+		if !resolved.Type().IsRegular() {
+			return nil
+		}
+		return v.loadPreview(resolved.Path())
 	}
 
 	v.childEnabled = true
@@ -372,6 +1070,28 @@ func (v *Model) loadChildDir() tea.Cmd {
 	return filesys.LoadChildCmd(v.childReqID, resolved.Path())
 }
 
+// loadPreview loads the preview of the file at path for the child pane.
+// It shares the request IDs of the child directory, so that whichever was
+// requested last wins.
+func (v *Model) loadPreview(path string) tea.Cmd {
+	v.stopPreview()
+	v.childReqID++
+	_, _, childW := v.calculatePaneWidths(v.termCols)
+
+	var ctx context.Context
+	ctx, v.cancelPreview = context.WithCancel(context.Background())
+	return v.previewer.LoadCmd(ctx, v.childReqID, path, childW, v.getFileHeight())
+}
+
+// stopPreview cancels loading a preview, stopping the previewer command if
+// it is still running.
+func (v *Model) stopPreview() {
+	if v.cancelPreview != nil {
+		v.cancelPreview()
+		v.cancelPreview = nil
+	}
+}
+
 func errorCmd(err error) tea.Cmd {
 	if err == nil {
 		return nil
@@ -395,6 +1115,19 @@ type Stats struct {
 
 // Info returns the current dir and selection stats.
 func (v *Model) Info() (Stats, error) {
+	if v.grepMode {
+		idx, total := v.grep.Position()
+		return Stats{Index: idx, Total: total, Name: v.grep.Status(), Mode: "grep"}, nil
+	}
+	if v.trashMode {
+		idx, total := v.trash.Position()
+		stats := Stats{Index: idx, Total: total, Mode: "trash"}
+		if e, ok := v.trash.CurrEntry(); ok {
+			stats.Name = e.Name()
+		}
+		return stats, nil
+	}
+
 	idx, total := v.wd.Position()
 	stats := Stats{
 		Index: idx,
@@ -428,4 +1161,10 @@ func (v *Model) updateLayout() {
 	v.pd.SetBounds(max(0, paneHeight), max(0, parentW))
 	v.wd.SetBounds(max(0, paneHeight), max(0, currentW))
 	v.cd.SetBounds(max(0, paneHeight), max(0, childW))
+	v.filePreview.SetMaxDims(max(0, paneHeight), max(0, childW))
+	v.trash.SetMaxDims(max(0, paneHeight), max(0, v.termCols-v.borderDeduction(1)))
+	v.grep.SetMaxDims(max(0, paneHeight), max(0, v.termCols-v.borderDeduction(1)))
+	v.confirm.SetMaxDims(v.termRows, v.termCols)
+	v.conflict.SetMaxDims(v.termRows, v.termCols)
+	v.finder.SetMaxDims(v.termRows, v.termCols)
 }
@@ -74,3 +74,55 @@ func (p *pane) SetDir(dir filesys.Dir, override filelist.State) {
 func (p *pane) SetShowHidden(show bool) {
 	p.view.SetShowHidden(show)
 }
+
+func (p *pane) StartRange() {
+	p.view.StartRange()
+}
+
+func (p *pane) Ranging() bool {
+	return p.view.Ranging()
+}
+
+func (p *pane) EndRange() []string {
+	return p.view.EndRange()
+}
+
+func (p *pane) CancelRange() {
+	p.view.CancelRange()
+}
+
+func (p *pane) VisiblePaths() []string {
+	return p.view.VisiblePaths()
+}
+
+func (p *pane) MatchPaths(pattern string) ([]string, error) {
+	return p.view.MatchPaths(pattern)
+}
+
+func (p *pane) StartSearch() {
+	p.view.StartSearch()
+}
+
+func (p *pane) Search(query string) bool {
+	return p.view.Search(query)
+}
+
+func (p *pane) CancelSearch() {
+	p.view.CancelSearch()
+}
+
+func (p *pane) ClearSearch() {
+	p.view.ClearSearch()
+}
+
+func (p *pane) SearchQuery() string {
+	return p.view.SearchQuery()
+}
+
+func (p *pane) NextMatch() bool {
+	return p.view.NextMatch()
+}
+
+func (p *pane) PrevMatch() bool {
+	return p.view.PrevMatch()
+}
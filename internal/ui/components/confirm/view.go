@@ -0,0 +1,134 @@
+package confirm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result describes the outcome of a key press handled by the dialog.
+type Result int
+
+const (
+	// None means the dialog is still waiting for an answer.
+	None Result = iota
+	// Yes means the user confirmed the operation.
+	Yes
+	// No means the user declined the operation.
+	No
+)
+
+// View is a modal dialog asking the user to confirm an operation on a set
+// of paths.
+type View struct {
+	title     string
+	paths     []string
+	size      int64
+	sizeKnown bool
+	active    bool
+	width     int
+	height    int
+}
+
+func New() *View {
+	return &View{}
+}
+
+// Open activates the dialog for the given paths. The total size is shown
+// once SetSize is called.
+func (v *View) Open(title string, paths []string) {
+	v.title = title
+	v.paths = paths
+	v.size = 0
+	v.sizeKnown = false
+	v.active = true
+}
+
+func (v *View) Close() {
+	v.active = false
+	v.paths = nil
+}
+
+func (v *View) Active() bool {
+	return v.active
+}
+
+func (v *View) SetSize(size int64) {
+	v.size = size
+	v.sizeKnown = true
+}
+
+// SetMaxDims sets the area the dialog is centered in.
+func (v *View) SetMaxDims(rows, cols int) {
+	v.height = max(0, rows)
+	v.width = max(0, cols)
+}
+
+// Update handles a key press. Only an explicit "y" confirms.
+func (v *View) Update(msg tea.KeyMsg) Result {
+	switch msg.String() {
+	case "y", "Y":
+		return Yes
+	case "n", "N", "esc", "q":
+		return No
+	}
+	return None
+}
+
+func (v *View) View() string {
+	if !v.active {
+		return ""
+	}
+
+	boxWidth := min(max(40, v.width*2/3), max(0, v.width-4))
+	innerWidth := max(0, boxWidth-4)
+
+	size := "calculating…"
+	if v.sizeKnown {
+		scaled, unit := util.ScaleSize(v.size)
+		size = fmt.Sprintf("%.2f %s", scaled, strings.TrimSpace(unit))
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.Red)
+	infoStyle := lipgloss.NewStyle().Foreground(theme.Subtext0)
+	pathStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	hintStyle := lipgloss.NewStyle().Foreground(theme.Overlay1)
+
+	lines := []string{
+		titleStyle.Render(v.title),
+		infoStyle.Render(fmt.Sprintf("%d %s, %s", len(v.paths), plural(len(v.paths), "item", "items"), size)),
+		"",
+	}
+
+	// Leave room for the header, the hint and the border
+	maxPaths := max(1, v.height-10)
+	for i, path := range v.paths {
+		if i == maxPaths && len(v.paths) > maxPaths {
+			lines = append(lines, infoStyle.Render(fmt.Sprintf("… and %d more", len(v.paths)-maxPaths)))
+			break
+		}
+		lines = append(lines, pathStyle.Render(util.TruncateLeft(path, innerWidth)))
+	}
+
+	lines = append(lines, "", hintStyle.Render("[y] yes   [n] no"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Red).
+		Padding(0, 1).
+		Width(boxWidth).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
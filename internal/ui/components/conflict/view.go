@@ -0,0 +1,134 @@
+package conflict
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alx99/sail/internal/filesys"
+	"github.com/alx99/sail/internal/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result describes the outcome of a key press handled by the dialog.
+type Result int
+
+const (
+	// None means there are conflicts left to resolve.
+	None Result = iota
+	// Done means every conflict has been resolved.
+	Done
+	// Cancel means the user aborted the operation.
+	Cancel
+)
+
+// View is a modal dialog that asks how to resolve each collision of a paste
+// operation, one at a time.
+type View struct {
+	conflicts   []filesys.Conflict
+	resolutions map[string]filesys.Resolution
+	idx         int
+	active      bool
+	width       int
+	height      int
+}
+
+func New() *View {
+	return &View{}
+}
+
+// Open activates the dialog for the given conflicts.
+func (v *View) Open(conflicts []filesys.Conflict) {
+	v.conflicts = conflicts
+	v.resolutions = make(map[string]filesys.Resolution, len(conflicts))
+	v.idx = 0
+	v.active = len(conflicts) > 0
+}
+
+func (v *View) Close() {
+	v.active = false
+	v.conflicts = nil
+}
+
+func (v *View) Active() bool {
+	return v.active
+}
+
+// Resolutions returns the chosen resolution for each conflicting source.
+func (v *View) Resolutions() map[string]filesys.Resolution {
+	return v.resolutions
+}
+
+// SetMaxDims sets the area the dialog is centered in.
+func (v *View) SetMaxDims(rows, cols int) {
+	v.height = max(0, rows)
+	v.width = max(0, cols)
+}
+
+// Update handles a key press. Lower case keys resolve the current conflict,
+// upper case keys resolve it and all remaining ones.
+func (v *View) Update(msg tea.KeyMsg) Result {
+	var res filesys.Resolution
+	key := msg.String()
+	switch strings.ToLower(key) {
+	case "o":
+		res = filesys.Overwrite
+	case "s":
+		res = filesys.Skip
+	case "r":
+		res = filesys.KeepBoth
+	case "esc", "q":
+		return Cancel
+	default:
+		return None
+	}
+
+	applyAll := key != strings.ToLower(key)
+	for ; v.idx < len(v.conflicts); v.idx++ {
+		v.resolutions[v.conflicts[v.idx].Src] = res
+		if !applyAll {
+			v.idx++
+			break
+		}
+	}
+
+	if v.idx >= len(v.conflicts) {
+		return Done
+	}
+	return None
+}
+
+func (v *View) View() string {
+	if !v.active || v.idx >= len(v.conflicts) {
+		return ""
+	}
+
+	c := v.conflicts[v.idx]
+	boxWidth := min(max(50, v.width*2/3), max(0, v.width-4))
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.Peach)
+	infoStyle := lipgloss.NewStyle().Foreground(theme.Subtext0)
+	pathStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	hintStyle := lipgloss.NewStyle().Foreground(theme.Overlay1)
+
+	lines := []string{
+		titleStyle.Render(fmt.Sprintf("Conflict %d of %d", v.idx+1, len(v.conflicts))),
+		"",
+		infoStyle.Render("Pasting"),
+		pathStyle.Render(c.Src),
+		infoStyle.Render("onto existing"),
+		pathStyle.Render(c.Dst),
+		"",
+		hintStyle.Render("[o] overwrite  [s] skip  [r] rename with suffix"),
+		hintStyle.Render("[O/S/R] apply to all remaining  [esc] cancel"),
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Peach).
+		Padding(0, 1).
+		Width(boxWidth).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, box)
+}
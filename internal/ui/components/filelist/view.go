@@ -1,12 +1,15 @@
 package filelist
 
 import (
+	"fmt"
 	"log/slog"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/alx99/sail/internal/filesys"
+	"github.com/alx99/sail/internal/fuzzy"
 	sstyle "github.com/alx99/sail/internal/style"
 	"github.com/alx99/sail/internal/ui/theme"
 	"github.com/charmbracelet/lipgloss"
@@ -35,6 +38,16 @@ type View struct {
 	cursorIndex    int
 	viewportStart  int
 	viewPortBuffer int
+	// anchor is the index the range being selected starts at, while
+	// ranging is set
+	anchor  int
+	ranging bool
+	// search is the current search query and hits maps the index of each
+	// entry matching it to the positions of the matched characters
+	search string
+	hits   map[int][]int
+	// searchOrigin is the entry the cursor was on when the search started
+	searchOrigin string
 }
 
 type State struct {
@@ -120,6 +133,9 @@ func (v *View) findClosestVisible(currentName string) string {
 }
 
 func (v *View) filterEntries() {
+	// The anchor indexes the old entries
+	v.ranging = false
+
 	if v.showHidden {
 		v.entries = v.allEntries
 	} else {
@@ -137,6 +153,7 @@ func (v *View) filterEntries() {
 		v.cursorIndex = 0
 	}
 	v.setIdealViewPort()
+	v.updateHits()
 }
 
 // View renders the FileList.
@@ -152,6 +169,7 @@ func (v *View) View() string {
 		file := v.entries[i]
 		currentFile := i == v.cursorIndex
 		selected := v.selChecker.IsSelected(filepath.Join(v.path, file.Name()))
+		inRange := v.inRange(i)
 
 		// Base style
 		var style lipgloss.Style
@@ -160,6 +178,9 @@ func (v *View) View() string {
 		} else {
 			style = v.styles.GetStyle(file)
 		}
+		if inRange {
+			style = style.Background(theme.Surface1)
+		}
 
 		// Icon
 		icon := sstyle.GetIcon(file.Name(), file.IsDir())
@@ -198,7 +219,7 @@ func (v *View) View() string {
 		}
 
 		// Render
-		renderedName := style.Render(icon + " " + name)
+		renderedName := style.Render(icon+" ") + v.renderName(name, v.hits[i], style)
 
 		// Fill remaining width if it's the cursor line to create a bar effect
 		// Note: We pad to v.maxWidth. Since we ensured content <= v.maxWidth,
@@ -227,7 +248,43 @@ func (v *View) View() string {
 	return v.sb.String()
 }
 
+// renderName renders name in style, highlighting the runes at the matched
+// positions.
+func (v *View) renderName(name string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(name)
+	}
+
+	hitStyle := style.Foreground(theme.Peach).Underline(true)
+	var sb strings.Builder
+	var run []rune
+	hit := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if hit {
+			sb.WriteString(hitStyle.Render(string(run)))
+		} else {
+			sb.WriteString(style.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range []rune(name) {
+		if h := slices.Contains(positions, i); h != hit {
+			flush()
+			hit = h
+		}
+		run = append(run, r)
+	}
+	flush()
+	return sb.String()
+}
+
 func (v *View) ChDir(dir filesys.Dir, state State) {
+	if dir.Path() != v.path {
+		v.search = ""
+	}
 	v.path = dir.Path()
 	v.allEntries = dir.Entries()
 	sortEntries(v.collator, v.allEntries)
@@ -368,6 +425,176 @@ func (v *View) State() State {
 	}
 }
 
+// StartRange starts selecting a range of entries, anchored at the entry
+// under the cursor. The range follows the cursor until it is ended.
+func (v *View) StartRange() {
+	if len(v.entries) == 0 {
+		return
+	}
+	v.anchor = v.cursorIndex
+	v.ranging = true
+}
+
+// Ranging reports whether a range is being selected.
+func (v *View) Ranging() bool {
+	return v.ranging
+}
+
+// EndRange stops selecting a range and returns the paths of the entries
+// between the anchor and the cursor, both included.
+func (v *View) EndRange() []string {
+	if !v.ranging {
+		return nil
+	}
+	v.ranging = false
+
+	lo, hi := min(v.anchor, v.cursorIndex), max(v.anchor, v.cursorIndex)
+	return v.paths(v.entries[lo : hi+1])
+}
+
+// CancelRange stops selecting a range without returning it.
+func (v *View) CancelRange() {
+	v.ranging = false
+}
+
+func (v *View) inRange(i int) bool {
+	return v.ranging && i >= min(v.anchor, v.cursorIndex) && i <= max(v.anchor, v.cursorIndex)
+}
+
+// VisiblePaths returns the paths of the listed entries. Hidden files are
+// only included while they are shown.
+func (v *View) VisiblePaths() []string {
+	return v.paths(v.entries)
+}
+
+// MatchPaths returns the paths of the listed entries whose name matches
+// pattern. A pattern enclosed in slashes, like /^IMG_\d+/, is a regular
+// expression, anything else is a glob.
+func (v *View) MatchPaths(pattern string) ([]string, error) {
+	match, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range v.entries {
+		if match(e.Name()) {
+			paths = append(paths, filepath.Join(v.path, e.Name()))
+		}
+	}
+	return paths, nil
+}
+
+func (v *View) paths(entries []filesys.DirEntry) []string {
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = filepath.Join(v.path, e.Name())
+	}
+	return paths
+}
+
+// StartSearch begins a search, remembering the entry under the cursor so
+// that it can be returned to.
+func (v *View) StartSearch() {
+	v.searchOrigin = ""
+	if e, ok := v.CurrEntry(); ok {
+		v.searchOrigin = e.Name()
+	}
+	v.search = ""
+	v.hits = nil
+}
+
+// Search highlights the entries fuzzy matching query and moves the cursor
+// to the best match. An empty query moves the cursor back to where the
+// search started. It reports whether any entry matched.
+func (v *View) Search(query string) bool {
+	v.search = query
+	if query == "" {
+		v.hits = nil
+		v.SelectFileByName(v.searchOrigin)
+		return false
+	}
+
+	results := fuzzy.Filter(query, v.names())
+	v.setHits(results)
+	if len(results) == 0 {
+		return false
+	}
+	v.moveTo(results[0].Index)
+	return true
+}
+
+// CancelSearch clears the search and moves the cursor back to where it
+// started.
+func (v *View) CancelSearch() {
+	v.ClearSearch()
+	v.SelectFileByName(v.searchOrigin)
+}
+
+// ClearSearch removes the highlights of the current search.
+func (v *View) ClearSearch() {
+	v.search = ""
+	v.hits = nil
+}
+
+// SearchQuery returns the current search query.
+func (v *View) SearchQuery() string {
+	return v.search
+}
+
+// NextMatch moves the cursor to the next entry matching the search,
+// wrapping around at the end. It reports whether there was one.
+func (v *View) NextMatch() bool {
+	for n := 1; n <= len(v.entries); n++ {
+		if i := (v.cursorIndex + n) % len(v.entries); v.hits[i] != nil {
+			v.moveTo(i)
+			return true
+		}
+	}
+	return false
+}
+
+// PrevMatch moves the cursor to the previous entry matching the search,
+// wrapping around at the start. It reports whether there was one.
+func (v *View) PrevMatch() bool {
+	for n := 1; n <= len(v.entries); n++ {
+		if i := (v.cursorIndex - n + len(v.entries)) % len(v.entries); v.hits[i] != nil {
+			v.moveTo(i)
+			return true
+		}
+	}
+	return false
+}
+
+// updateHits matches the search against the current entries again.
+func (v *View) updateHits() {
+	if v.search == "" {
+		v.hits = nil
+		return
+	}
+	v.setHits(fuzzy.Filter(v.search, v.names()))
+}
+
+func (v *View) setHits(results []fuzzy.Result) {
+	v.hits = make(map[int][]int, len(results))
+	for _, r := range results {
+		v.hits[r.Index] = r.Positions
+	}
+}
+
+func (v *View) names() []string {
+	names := make([]string, len(v.entries))
+	for i, e := range v.entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func (v *View) moveTo(i int) {
+	v.cursorIndex = i
+	v.setIdealViewPort()
+}
+
 func (v *View) SelectedRow() int {
 	return max(0, v.cursorIndex-v.viewportStart)
 }
@@ -379,6 +606,26 @@ func (v *View) Position() (int, int) {
 	return v.cursorIndex, len(v.entries)
 }
 
+// compilePattern returns a function matching names against pattern, see
+// MatchPaths.
+func compilePattern(pattern string) (func(string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return func(name string) bool {
+		ok, _ := filepath.Match(pattern, name)
+		return ok
+	}, nil
+}
+
 func isHidden(name string) bool {
 	return strings.HasPrefix(name, ".")
 }
@@ -76,3 +76,118 @@ func assertSameOrder(t *testing.T, expected, actual []filesys.DirEntry) {
 		}
 	}
 }
+
+func newTestView(t *testing.T, names ...string) (*View, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0o644); err != nil {
+			t.Fatalf("create file %q: %v", name, err)
+		}
+	}
+	d, err := filesys.NewDir(dir)
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+
+	v := New(dir, State{}, stubSel{}, collator.New(), true, style.NewStyles(""))
+	v.SetMaxDims(10, 40)
+	v.ChDir(d, State{})
+	return v, dir
+}
+
+func TestRangeSelection(t *testing.T) {
+	v, dir := newTestView(t, "a", "b", "c", "d")
+
+	v.MoveDown()
+	v.StartRange()
+	v.MoveDown()
+	v.MoveDown()
+	got := v.EndRange()
+	want := []string{filepath.Join(dir, "b"), filepath.Join(dir, "c"), filepath.Join(dir, "d")}
+	if !slices.Equal(got, want) {
+		t.Fatalf("EndRange() = %q, want %q", got, want)
+	}
+	if v.Ranging() {
+		t.Fatal("still ranging after EndRange")
+	}
+
+	// Ranges work upwards too
+	v.StartRange()
+	v.MoveUp()
+	if got := v.EndRange(); !slices.Equal(got, want[1:]) {
+		t.Fatalf("EndRange() = %q, want %q", got, want[1:])
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	v, dir := newTestView(t, "IMG_1.jpg", "IMG_2.png", "notes.txt", ".hidden.jpg")
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.jpg", []string{"IMG_1.jpg"}},
+		{"IMG_*", []string{"IMG_1.jpg", "IMG_2.png"}},
+		{`/^IMG_\d\.png$/`, []string{"IMG_2.png"}},
+		{"/txt/", []string{"notes.txt"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		got, err := v.MatchPaths(tt.pattern)
+		if err != nil {
+			t.Fatalf("MatchPaths(%q): %v", tt.pattern, err)
+		}
+		var want []string
+		for _, name := range tt.want {
+			want = append(want, filepath.Join(dir, name))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("MatchPaths(%q) = %q, want %q", tt.pattern, got, want)
+		}
+	}
+
+	for _, pattern := range []string{"[", "/(/"} {
+		if _, err := v.MatchPaths(pattern); err == nil {
+			t.Errorf("MatchPaths(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	v, _ := newTestView(t, "alpha", "beta", "gamma", "main.go", "makefile", ".mailrc")
+
+	v.MoveDown()
+	v.StartSearch()
+	if !v.Search("ma") {
+		t.Fatal("Search(\"ma\") found nothing")
+	}
+	if e, _ := v.CurrEntry(); e.Name() != "main.go" && e.Name() != "makefile" {
+		t.Fatalf("cursor on %q, want a name starting with ma", e.Name())
+	}
+
+	// Hidden entries are only searched while shown
+	var names []string
+	for range len(v.hits) {
+		v.NextMatch()
+		e, _ := v.CurrEntry()
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	if want := []string{"gamma", "main.go", "makefile"}; !slices.Equal(names, want) {
+		t.Fatalf("matches = %q, want %q", names, want)
+	}
+	v.SetShowHidden(true)
+	if _, ok := v.hits[slices.IndexFunc(v.entries, func(e filesys.DirEntry) bool { return e.Name() == ".mailrc" })]; !ok {
+		t.Fatal(".mailrc not matched once hidden files are shown")
+	}
+
+	v.CancelSearch()
+	if e, _ := v.CurrEntry(); e.Name() != "beta" {
+		t.Fatalf("cursor on %q after cancelling, want beta", e.Name())
+	}
+	if v.NextMatch() {
+		t.Fatal("NextMatch() succeeded after the search was cancelled")
+	}
+}
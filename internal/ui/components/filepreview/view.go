@@ -0,0 +1,78 @@
+package filepreview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alx99/sail/internal/preview"
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// View shows the preview of a file, clipped to the pane it is drawn in.
+type View struct {
+	preview preview.Preview
+	loaded  bool
+
+	maxHeight int
+	maxWidth  int
+}
+
+func New() *View {
+	return &View{}
+}
+
+// SetPreview replaces what is shown.
+func (v *View) SetPreview(p preview.Preview) {
+	v.preview = p
+	v.loaded = true
+}
+
+func (v *View) SetMaxDims(rows, cols int) {
+	v.maxHeight = max(0, rows)
+	v.maxWidth = max(0, cols)
+}
+
+func (v *View) View() string {
+	if !v.loaded {
+		return ""
+	}
+
+	infoStyle := theme.DefaultTheme.StatusInfo
+	if len(v.preview.Lines) == 0 {
+		return lipgloss.NewStyle().Width(v.maxWidth).Align(lipgloss.Center).Render(infoStyle.Render("Empty file"))
+	}
+
+	lines := v.preview.Lines[:min(len(v.preview.Lines), v.maxHeight)]
+	if v.preview.Truncated && len(lines) == v.maxHeight && v.maxHeight > 1 {
+		// Make room for telling that not all of the file was read
+		lines = lines[:len(lines)-1]
+	}
+
+	gutter := 0
+	if v.preview.Numbered {
+		gutter = len(strconv.Itoa(len(lines))) + 1
+	}
+	numStyle := lipgloss.NewStyle().Foreground(theme.Overlay0)
+
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if gutter > 0 {
+			sb.WriteString(numStyle.Render(fmt.Sprintf("%*d ", gutter-1, i+1)))
+		}
+		sb.WriteString(ansi.Truncate(line, max(0, v.maxWidth-gutter), ""))
+	}
+
+	if v.preview.Truncated && len(lines) < v.maxHeight {
+		size, unit := util.ScaleSize(v.preview.Size)
+		note := fmt.Sprintf("… %.1f %s in total", size, strings.TrimSpace(unit))
+		sb.WriteString("\n" + infoStyle.Render(ansi.Truncate(note, v.maxWidth, "")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,248 @@
+package finder
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alx99/sail/internal/fuzzy"
+	"github.com/alx99/sail/internal/ui/components/prompt"
+	"github.com/alx99/sail/internal/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result describes the outcome of a key press handled by the finder.
+type Result int
+
+const (
+	// None means the user is still searching.
+	None Result = iota
+	// Pick means the user chose the current entry.
+	Pick
+	// Cancel means the user closed the finder.
+	Cancel
+)
+
+// View is an overlay listing the paths below a directory, ranked by how
+// well they fuzzy match the query typed into it. Paths can be added while
+// it is open.
+type View struct {
+	input   *prompt.View
+	root    string
+	paths   []string
+	results []fuzzy.Result
+	query   string
+	done    bool
+
+	cursor        int
+	viewportStart int
+	active        bool
+	width         int
+	height        int
+}
+
+func New() *View {
+	return &View{input: prompt.New()}
+}
+
+// Open activates the finder for the tree below root, with no paths yet.
+func (v *View) Open(root string) {
+	v.root = root
+	v.paths = nil
+	v.results = nil
+	v.query = ""
+	v.done = false
+	v.cursor = 0
+	v.viewportStart = 0
+	v.active = true
+	v.input.Open("Find", "")
+}
+
+func (v *View) Close() {
+	v.active = false
+	v.paths = nil
+	v.results = nil
+	v.input.Close()
+}
+
+func (v *View) Active() bool {
+	return v.active
+}
+
+// Append adds found paths, relative to the root.
+func (v *View) Append(paths []string) {
+	start := len(v.paths)
+	v.paths = append(v.paths, paths...)
+
+	for i, path := range paths {
+		if score, positions, ok := fuzzy.Match(v.query, path); ok {
+			v.results = append(v.results, fuzzy.Result{Index: start + i, Score: score, Positions: positions})
+		}
+	}
+	if v.query != "" {
+		fuzzy.Sort(v.results, v.paths)
+	}
+	v.fixViewport()
+}
+
+// SetDone marks the walk as finished.
+func (v *View) SetDone() {
+	v.done = true
+}
+
+// SetMaxDims sets the area the finder is drawn in.
+func (v *View) SetMaxDims(rows, cols int) {
+	v.height = max(0, rows)
+	v.width = max(0, cols)
+	v.input.SetWidth(v.boxWidth() - 4)
+	v.fixViewport()
+}
+
+// Update handles a key press.
+func (v *View) Update(msg tea.KeyMsg) Result {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyCtrlP:
+		v.cursor = max(0, v.cursor-1)
+		v.fixViewport()
+		return None
+	case tea.KeyDown, tea.KeyCtrlN:
+		v.cursor = min(max(0, len(v.results)-1), v.cursor+1)
+		v.fixViewport()
+		return None
+	}
+
+	switch v.input.Update(msg) {
+	case prompt.Submit:
+		if len(v.results) == 0 {
+			return None
+		}
+		return Pick
+	case prompt.Cancel:
+		return Cancel
+	}
+	if query := v.input.Value(); query != v.query {
+		v.query = query
+		v.filter()
+	}
+	return None
+}
+
+// filter ranks the paths against the query. Without a query they are
+// listed in the order they were found.
+func (v *View) filter() {
+	if v.query == "" {
+		v.results = make([]fuzzy.Result, len(v.paths))
+		for i := range v.paths {
+			v.results[i] = fuzzy.Result{Index: i}
+		}
+	} else {
+		v.results = fuzzy.Filter(v.query, v.paths)
+	}
+	v.cursor = 0
+	v.fixViewport()
+}
+
+// Picked returns the absolute path of the entry under the cursor.
+func (v *View) Picked() (string, bool) {
+	if v.cursor >= len(v.results) {
+		return "", false
+	}
+	rel := strings.TrimSuffix(v.paths[v.results[v.cursor].Index], string(filepath.Separator))
+	return filepath.Join(v.root, rel), true
+}
+
+// listHeight is the number of results shown, leaving room for the border,
+// the input and the counter.
+func (v *View) listHeight() int {
+	return max(1, v.height*2/3-4)
+}
+
+func (v *View) boxWidth() int {
+	return min(max(40, v.width*2/3), max(0, v.width-4))
+}
+
+func (v *View) fixViewport() {
+	v.cursor = min(v.cursor, max(0, len(v.results)-1))
+	rows := v.listHeight()
+	if v.cursor < v.viewportStart {
+		v.viewportStart = v.cursor
+	} else if v.cursor >= v.viewportStart+rows {
+		v.viewportStart = v.cursor - rows + 1
+	}
+}
+
+func (v *View) View() string {
+	if !v.active {
+		return ""
+	}
+
+	boxWidth := v.boxWidth()
+	innerWidth := max(0, boxWidth-4)
+	infoStyle := lipgloss.NewStyle().Foreground(theme.Subtext0)
+
+	lines := []string{v.input.View()}
+
+	status := fmt.Sprintf("%d/%d", len(v.results), len(v.paths))
+	if !v.done {
+		status += " searching…"
+	}
+	lines = append(lines, infoStyle.Render(status))
+
+	rows := v.listHeight()
+	end := min(len(v.results), v.viewportStart+rows)
+	for i := v.viewportStart; i < end; i++ {
+		r := v.results[i]
+		lines = append(lines, v.renderRow(v.paths[r.Index], r.Positions, i == v.cursor, innerWidth))
+	}
+	for i := end - v.viewportStart; i < rows; i++ {
+		lines = append(lines, "")
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Blue).
+		Padding(0, 1).
+		Width(boxWidth).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(v.width, v.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// renderRow renders path, highlighting the matched characters. Long paths
+// are cut from the start, as their end is the most specific part.
+func (v *View) renderRow(path string, positions []int, current bool, width int) string {
+	style := lipgloss.NewStyle().Foreground(theme.Text)
+	if strings.HasSuffix(path, string(filepath.Separator)) {
+		style = style.Foreground(theme.Blue)
+	}
+	if current {
+		style = style.Background(theme.Surface2).Bold(true)
+	}
+	hitStyle := style.Foreground(theme.Peach).Underline(true)
+
+	runes := []rune(path)
+	offset := 0
+	if len(runes) > width && width > 1 {
+		offset = len(runes) - width + 1
+	}
+
+	var sb strings.Builder
+	if offset > 0 {
+		sb.WriteString(style.Render("…"))
+	}
+	for i := offset; i < len(runes); i++ {
+		if slices.Contains(positions, i) {
+			sb.WriteString(hitStyle.Render(string(runes[i])))
+		} else {
+			sb.WriteString(style.Render(string(runes[i])))
+		}
+	}
+	if current {
+		if pad := width - lipgloss.Width(sb.String()); pad > 0 {
+			sb.WriteString(style.Render(strings.Repeat(" ", pad)))
+		}
+	}
+	return sb.String()
+}
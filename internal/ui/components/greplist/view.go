@@ -0,0 +1,166 @@
+package greplist
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alx99/sail/internal/filesys"
+	"github.com/alx99/sail/internal/ui/components/listcursor"
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// View lists the matches of a content search as path:line:snippet.
+type View struct {
+	root      string
+	pattern   string
+	matches   []filesys.GrepMatch
+	done      bool
+	truncated bool
+
+	sb        strings.Builder
+	maxHeight int
+	maxWidth  int
+	cursor    listcursor.Cursor
+}
+
+func New() *View {
+	return &View{}
+}
+
+// Reset clears the list for a new search of pattern below root.
+func (v *View) Reset(root, pattern string) {
+	v.root = root
+	v.pattern = pattern
+	v.matches = nil
+	v.done = false
+	v.truncated = false
+	v.cursor.Reset()
+}
+
+// Append adds matches to the end of the list.
+func (v *View) Append(matches []filesys.GrepMatch) {
+	v.matches = append(v.matches, matches...)
+	v.cursor.Fix(len(v.matches), v.maxHeight)
+}
+
+// SetDone marks the search as finished. truncated is set if it stopped
+// before searching every file.
+func (v *View) SetDone(truncated bool) {
+	v.done = true
+	v.truncated = truncated
+}
+
+func (v *View) SetMaxDims(rows, cols int) {
+	if rows <= 0 || cols <= 0 {
+		return
+	}
+	v.maxHeight = rows
+	v.maxWidth = cols
+	v.cursor.Fix(len(v.matches), v.maxHeight)
+}
+
+// MoveUp moves the cursor up, wrapping around at the top.
+func (v *View) MoveUp() {
+	v.cursor.Up(len(v.matches), v.maxHeight)
+}
+
+// MoveDown moves the cursor down, wrapping around at the bottom.
+func (v *View) MoveDown() {
+	v.cursor.Down(len(v.matches), v.maxHeight)
+}
+
+func (v *View) CurrMatch() (filesys.GrepMatch, bool) {
+	if v.cursor.Index < 0 || v.cursor.Index >= len(v.matches) {
+		return filesys.GrepMatch{}, false
+	}
+	return v.matches[v.cursor.Index], true
+}
+
+func (v *View) Position() (int, int) {
+	if len(v.matches) == 0 {
+		return 0, 0
+	}
+	return v.cursor.Index, len(v.matches)
+}
+
+// Status describes the progress of the search.
+func (v *View) Status() string {
+	switch {
+	case !v.done:
+		return fmt.Sprintf("searching for %q…", v.pattern)
+	case v.truncated:
+		return fmt.Sprintf("stopped after %d matches for %q", len(v.matches), v.pattern)
+	}
+	return fmt.Sprintf("%d matches for %q", len(v.matches), v.pattern)
+}
+
+func (v *View) View() string {
+	v.sb.Reset()
+
+	if len(v.matches) == 0 {
+		msg := "No matches"
+		if !v.done {
+			msg = "Searching…"
+		}
+		msg = theme.DefaultTheme.StatusInfo.Render(msg)
+		return lipgloss.NewStyle().Width(v.maxWidth).Align(lipgloss.Center).Render(msg)
+	}
+
+	end := min(v.cursor.Start+v.maxHeight, len(v.matches))
+	for i := v.cursor.Start; i < end; i++ {
+		v.sb.WriteString(v.renderRow(v.matches[i], i == v.cursor.Index))
+		if i != end-1 {
+			v.sb.WriteString("\n")
+		}
+	}
+	return v.sb.String()
+}
+
+// renderRow renders a match, giving the snippet whatever room the location
+// leaves.
+func (v *View) renderRow(m filesys.GrepMatch, isCursor bool) string {
+	pathStyle := lipgloss.NewStyle().Foreground(theme.Blue)
+	lineStyle := lipgloss.NewStyle().Foreground(theme.Peach)
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	if isCursor {
+		pathStyle = pathStyle.Background(theme.Surface2).Bold(true)
+		lineStyle = lineStyle.Background(theme.Surface2)
+		textStyle = textStyle.Background(theme.Surface2)
+	}
+	hitStyle := textStyle.Foreground(theme.Yellow).Bold(true)
+
+	path := m.Path
+	if rel, err := filepath.Rel(v.root, m.Path); err == nil {
+		path = rel
+	}
+	path = util.Truncate(path, v.maxWidth/2)
+	loc := ":" + strconv.Itoa(m.Line) + ":"
+	avail := max(0, v.maxWidth-lipgloss.Width(path)-len(loc))
+
+	// Keep the match visible when the snippet has to be cut
+	text, start, end := m.Text, m.Start, m.End
+	if lipgloss.Width(text) > avail && start > avail/4 {
+		cut := start - avail/4
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text, start, end = "…"+text[cut:], start-cut+len("…"), end-cut+len("…")
+	}
+	body, ellipsis := util.Truncate(text, avail), ""
+	if body != text && body != "" {
+		body, ellipsis = strings.TrimSuffix(body, "…"), "…"
+	}
+	start, end = min(start, len(body)), min(end, len(body))
+
+	row := pathStyle.Render(path) + lineStyle.Render(loc) +
+		textStyle.Render(body[:start]) + hitStyle.Render(body[start:end]) + textStyle.Render(body[end:]+ellipsis)
+	if pad := v.maxWidth - lipgloss.Width(row); pad > 0 && isCursor {
+		row += textStyle.Render(strings.Repeat(" ", pad))
+	}
+	return row
+}
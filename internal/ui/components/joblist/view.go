@@ -0,0 +1,167 @@
+package joblist
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alx99/sail/internal/filesys"
+	"github.com/alx99/sail/internal/ui/components/listcursor"
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRows is the number of jobs visible at once.
+const maxRows = 6
+
+// View is a panel listing the jobs known to a filesys.JobRegistry.
+type View struct {
+	records []filesys.JobRecord
+
+	sb     strings.Builder
+	width  int
+	cursor listcursor.Cursor
+}
+
+func New() *View {
+	return &View{}
+}
+
+// SetRecords replaces the listed jobs, keeping the cursor on the same job
+// if it still exists.
+func (v *View) SetRecords(records []filesys.JobRecord) {
+	curr, hadCurr := v.CurrRecord()
+
+	v.records = records
+	for i, r := range records {
+		if hadCurr && r.ID == curr.ID {
+			v.cursor.Index = i
+		}
+	}
+	v.cursor.Fix(len(v.records), maxRows)
+}
+
+func (v *View) SetWidth(cols int) {
+	v.width = max(0, cols)
+}
+
+// Height returns the number of lines the panel occupies, which does not
+// depend on the number of jobs.
+func (v *View) Height() int {
+	return maxRows + 3 // title and border
+}
+
+// MoveUp moves the cursor up, wrapping around at the top.
+func (v *View) MoveUp() {
+	v.cursor.Up(len(v.records), maxRows)
+}
+
+// MoveDown moves the cursor down, wrapping around at the bottom.
+func (v *View) MoveDown() {
+	v.cursor.Down(len(v.records), maxRows)
+}
+
+func (v *View) CurrRecord() (filesys.JobRecord, bool) {
+	if v.cursor.Index < 0 || v.cursor.Index >= len(v.records) {
+		return filesys.JobRecord{}, false
+	}
+	return v.records[v.cursor.Index], true
+}
+
+func (v *View) View() string {
+	v.sb.Reset()
+	innerWidth := max(0, v.width-4)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.Peach)
+	v.sb.WriteString(titleStyle.Render(fmt.Sprintf("Jobs (%d)", len(v.records))))
+
+	if len(v.records) == 0 {
+		v.sb.WriteString("\n")
+		v.sb.WriteString(theme.DefaultTheme.StatusInfo.Render("No jobs"))
+	}
+
+	end := min(v.cursor.Start+maxRows, len(v.records))
+	for i := v.cursor.Start; i < end; i++ {
+		v.sb.WriteString("\n")
+		v.sb.WriteString(v.renderRow(v.records[i], i == v.cursor.Index, innerWidth))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Overlay0).
+		Padding(0, 1).
+		Width(max(0, v.width-2)).
+		Height(v.Height() - 2).
+		Render(v.sb.String())
+}
+
+// renderRow renders a job as its state, kind, paths and error on the left
+// and its progress on the right.
+func (v *View) renderRow(r filesys.JobRecord, isCursor bool, width int) string {
+	stateStyle := lipgloss.NewStyle().Width(8).Foreground(stateColor(r.State))
+	kindStyle := lipgloss.NewStyle().Width(7).Foreground(theme.Subtext0)
+	pathStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	errStyle := lipgloss.NewStyle().Foreground(theme.Red)
+	statStyle := lipgloss.NewStyle().Foreground(theme.Overlay1)
+	if isCursor {
+		for _, s := range []*lipgloss.Style{&stateStyle, &kindStyle, &pathStyle, &errStyle, &statStyle} {
+			*s = s.Background(theme.Surface2)
+		}
+		pathStyle = pathStyle.Bold(true)
+	}
+
+	stats := " " + throughput(r.Throughput())
+	if r.State == filesys.JobRunning {
+		stats = fmt.Sprintf(" %d%%%s", r.Stat.Percent(), stats)
+	}
+
+	paths := describePaths(r.JobInfo)
+	errText := ""
+	if r.Err != nil {
+		errText = "  " + r.Err.Error()
+	}
+
+	avail := max(0, width-16-lipgloss.Width(stats))
+	paths = util.Truncate(paths, avail)
+	errText = util.Truncate(errText, avail-lipgloss.Width(paths))
+	pad := max(0, avail-lipgloss.Width(paths)-lipgloss.Width(errText))
+
+	return stateStyle.Render(r.State.String()) +
+		kindStyle.Render(r.Kind.String()) +
+		pathStyle.Render(paths) +
+		errStyle.Render(errText+strings.Repeat(" ", pad)) +
+		statStyle.Render(stats)
+}
+
+// describePaths summarizes the sources and destination of a job.
+func describePaths(info filesys.JobInfo) string {
+	var src string
+	if len(info.Paths) > 0 {
+		src = filepath.Base(info.Paths[0])
+	}
+	if len(info.Paths) > 1 {
+		src += fmt.Sprintf(" +%d", len(info.Paths)-1)
+	}
+	if info.Dst == "" {
+		return src
+	}
+	return src + " → " + info.Dst
+}
+
+func throughput(bytesPerSec int64) string {
+	size, unit := util.ScaleSize(bytesPerSec)
+	return fmt.Sprintf("%.2f %s/s", size, strings.TrimSpace(unit))
+}
+
+func stateColor(s filesys.JobState) lipgloss.Color {
+	switch s {
+	case filesys.JobRunning:
+		return theme.Peach
+	case filesys.JobFailed:
+		return theme.Red
+	case filesys.JobDone:
+		return theme.Green
+	}
+	return theme.Overlay1
+}
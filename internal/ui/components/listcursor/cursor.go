@@ -0,0 +1,51 @@
+// Package listcursor moves the cursor of a list and keeps it in view.
+package listcursor
+
+// Cursor is the position of the cursor in a list of n rows, of which
+// height are shown at once. The zero value is at the first row.
+type Cursor struct {
+	// Index is the row under the cursor.
+	Index int
+	// Start is the first row in view.
+	Start int
+}
+
+// Reset moves the cursor back to the first row.
+func (c *Cursor) Reset() {
+	c.Index, c.Start = 0, 0
+}
+
+// Up moves the cursor up, wrapping around at the top.
+func (c *Cursor) Up(n, height int) {
+	if n == 0 {
+		return
+	}
+	c.Index = (c.Index - 1 + n) % n
+	c.Fix(n, height)
+}
+
+// Down moves the cursor down, wrapping around at the bottom.
+func (c *Cursor) Down(n, height int) {
+	if n == 0 {
+		return
+	}
+	c.Index = (c.Index + 1) % n
+	c.Fix(n, height)
+}
+
+// Fix keeps the cursor on one of the n rows, and scrolls as little as
+// possible to bring it into view.
+func (c *Cursor) Fix(n, height int) {
+	c.Index = max(0, min(c.Index, n-1))
+	if height <= 0 {
+		c.Start = 0
+		return
+	}
+	if c.Index < c.Start {
+		c.Start = c.Index
+	}
+	if c.Index >= c.Start+height {
+		c.Start = c.Index - height + 1
+	}
+	c.Start = max(0, min(c.Start, n-height))
+}
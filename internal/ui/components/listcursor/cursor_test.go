@@ -0,0 +1,27 @@
+package listcursor
+
+import "testing"
+
+func TestCursor(t *testing.T) {
+	var c Cursor
+	c.Up(10, 3)
+	if c.Index != 9 || c.Start != 7 {
+		t.Fatalf("after wrapping up got %+v, want index 9 from 7", c)
+	}
+	c.Down(10, 3)
+	if c.Index != 0 || c.Start != 0 {
+		t.Fatalf("after wrapping down got %+v, want index 0 from 0", c)
+	}
+
+	// Shrinking the list pulls the cursor along
+	c = Cursor{Index: 8, Start: 6}
+	c.Fix(4, 3)
+	if c.Index != 3 || c.Start != 1 {
+		t.Fatalf("after shrinking got %+v, want index 3 from 1", c)
+	}
+
+	c.Fix(0, 3)
+	if c.Index != 0 || c.Start != 0 {
+		t.Fatalf("on an empty list got %+v", c)
+	}
+}
@@ -0,0 +1,155 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/alx99/sail/internal/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result describes the outcome of a key press handled by the prompt.
+type Result int
+
+const (
+	// None means the prompt is still being edited.
+	None Result = iota
+	// Submit means the user confirmed the input.
+	Submit
+	// Cancel means the user aborted the input.
+	Cancel
+)
+
+// View is a single line text input.
+type View struct {
+	label  string
+	value  []rune
+	cursor int
+	width  int
+	active bool
+}
+
+func New() *View {
+	return &View{}
+}
+
+// Open activates the prompt with the given label and initial value.
+// The cursor is placed at the end of the value.
+func (v *View) Open(label, value string) {
+	v.label = label
+	v.value = []rune(value)
+	v.cursor = len(v.value)
+	v.active = true
+}
+
+// Close deactivates the prompt and discards its value.
+func (v *View) Close() {
+	v.active = false
+	v.value = nil
+	v.cursor = 0
+}
+
+func (v *View) Active() bool {
+	return v.active
+}
+
+func (v *View) Value() string {
+	return string(v.value)
+}
+
+// SetCursor moves the cursor to the given rune offset.
+func (v *View) SetCursor(pos int) {
+	v.cursor = min(max(0, pos), len(v.value))
+}
+
+func (v *View) SetWidth(width int) {
+	v.width = max(0, width)
+}
+
+// Update applies a key press to the input.
+func (v *View) Update(msg tea.KeyMsg) Result {
+	switch msg.Type {
+	case tea.KeyEnter:
+		return Submit
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return Cancel
+	case tea.KeyRunes, tea.KeySpace:
+		v.insert(msg.Runes)
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if v.cursor > 0 {
+			v.value = append(v.value[:v.cursor-1], v.value[v.cursor:]...)
+			v.cursor--
+		}
+	case tea.KeyDelete, tea.KeyCtrlD:
+		if v.cursor < len(v.value) {
+			v.value = append(v.value[:v.cursor], v.value[v.cursor+1:]...)
+		}
+	case tea.KeyLeft, tea.KeyCtrlB:
+		v.cursor = max(0, v.cursor-1)
+	case tea.KeyRight, tea.KeyCtrlF:
+		v.cursor = min(len(v.value), v.cursor+1)
+	case tea.KeyHome, tea.KeyCtrlA:
+		v.cursor = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		v.cursor = len(v.value)
+	case tea.KeyCtrlU:
+		v.value = v.value[v.cursor:]
+		v.cursor = 0
+	case tea.KeyCtrlK:
+		v.value = v.value[:v.cursor]
+	case tea.KeyCtrlW:
+		start := v.cursor
+		for start > 0 && unicode.IsSpace(v.value[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(v.value[start-1]) && v.value[start-1] != '/' {
+			start--
+		}
+		v.value = append(v.value[:start], v.value[v.cursor:]...)
+		v.cursor = start
+	}
+	return None
+}
+
+func (v *View) insert(runes []rune) {
+	tail := append([]rune{}, v.value[v.cursor:]...)
+	v.value = append(append(v.value[:v.cursor], runes...), tail...)
+	v.cursor += len(runes)
+}
+
+// View renders the prompt as a single line filling the configured width.
+func (v *View) View() string {
+	if !v.active {
+		return ""
+	}
+
+	label := theme.DefaultTheme.StatusMode.Render(v.label)
+	avail := max(1, v.width-lipgloss.Width(label)-1)
+
+	// Scroll the value so the cursor is always visible
+	start := 0
+	if v.cursor >= avail {
+		start = v.cursor - avail + 1
+	}
+	end := min(len(v.value), start+avail)
+
+	var sb strings.Builder
+	textStyle := theme.DefaultTheme.StatusBar
+	sb.WriteString(textStyle.Render(string(v.value[start:min(v.cursor, end)])))
+
+	cursorChar := " "
+	if v.cursor < len(v.value) {
+		cursorChar = string(v.value[v.cursor])
+	}
+	sb.WriteString(theme.DefaultTheme.Cursor.Reverse(true).Render(cursorChar))
+	if v.cursor+1 < end {
+		sb.WriteString(textStyle.Render(string(v.value[v.cursor+1 : end])))
+	}
+
+	line := lipgloss.JoinHorizontal(lipgloss.Top, label, textStyle.Render(" "), sb.String())
+	if w := lipgloss.Width(line); w < v.width {
+		line += textStyle.Render(strings.Repeat(" ", v.width-w))
+	}
+	return line
+}
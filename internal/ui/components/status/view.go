@@ -10,12 +10,16 @@ import (
 	"github.com/alx99/sail/internal/filesys"
 	"github.com/alx99/sail/internal/ui/browser"
 	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var errDur = 1 * time.Second
 
+// infoDur is how long informational messages are shown.
+var infoDur = 3 * time.Second
+
 const sizeAnimInterval = 120 * time.Millisecond
 
 var sizeAnimFrames = []string{
@@ -47,6 +51,9 @@ type pillSegment struct {
 type Stats struct {
 	// SelectionCount is the current selection count.
 	SelectionCount int
+	// ClipOp and ClipCount describe the clipboard contents.
+	ClipOp    filesys.ClipOp
+	ClipCount int
 	browser.Stats
 }
 
@@ -57,22 +64,33 @@ type View struct {
 	errorAt time.Time
 	error   error
 
-	dirSize  int64
-	selIdx   int
-	selTotal int
-	selCount int
-	selName  string
-	selMode  string
+	infoAt time.Time
+	info   string
+
+	dirSize   int64
+	selIdx    int
+	selTotal  int
+	selCount  int
+	clipOp    filesys.ClipOp
+	clipCount int
+	selName   string
+	selMode   string
 
 	cancel context.CancelFunc
 
+	jobs     map[int]filesys.ProgressStat
+	jobKinds map[int]filesys.JobKind
+
 	animIdx     int
 	animRunning bool
 	animSeq     int
 }
 
 func New() *View {
-	return &View{}
+	return &View{
+		jobs:     make(map[int]filesys.ProgressStat),
+		jobKinds: make(map[int]filesys.JobKind),
+	}
 }
 
 func (v *View) Init() tea.Cmd {
@@ -97,6 +115,16 @@ func (v *View) Update(msg tea.Msg) tea.Cmd {
 		}
 		v.animIdx = (v.animIdx + 1) % len(sizeAnimFrames)
 		return v.sizeTick()
+	case filesys.JobStartedMsg:
+		v.jobs[msg.ID] = filesys.ProgressStat{}
+		v.jobKinds[msg.ID] = msg.Kind
+	case filesys.JobProgressMsg:
+		if _, ok := v.jobs[msg.ID]; ok {
+			v.jobs[msg.ID] = msg.Stat
+		}
+	case filesys.JobFinishedMsg:
+		delete(v.jobs, msg.ID)
+		delete(v.jobKinds, msg.ID)
 	case error:
 		// If size calculation returned an error, stop animating.
 		v.animRunning = false
@@ -107,6 +135,9 @@ func (v *View) Update(msg tea.Msg) tea.Cmd {
 
 func (v *View) View() string {
 	pathStr := v.wd.Path()
+	if v.info != "" && time.Now().Before(v.infoAt.Add(infoDur)) {
+		pathStr = v.info // Show message in path area temporarily
+	}
 	if v.error != nil && time.Now().Before(v.errorAt.Add(errDur)) {
 		pathStr = v.error.Error() // Show error in path area temporarily
 	}
@@ -136,16 +167,20 @@ func (v *View) View() string {
 
 	left := lipgloss.JoinHorizontal(lipgloss.Top, mode, path)
 
-	// Info segments: selection count + cursor position + size
-	info := renderPills(
-		[]pillSegment{
-			{text: v.viewSelectionCount(), bg: theme.Green},
-			{text: v.viewSelection(), bg: theme.Mauve, minWidth: 7},
-			{text: sizeText, bg: theme.Sapphire, minWidth: 7},
-		},
-		theme.Base,
-		theme.Surface0,
+	// Info segments: clipboard + selection count + cursor position + jobs + size
+	var pills []pillSegment
+	if v.clipCount > 0 {
+		pills = append(pills, pillSegment{text: fmt.Sprintf("%s %d", v.clipOp, v.clipCount), bg: theme.Yellow})
+	}
+	pills = append(pills,
+		pillSegment{text: v.viewSelectionCount(), bg: theme.Green},
+		pillSegment{text: v.viewSelection(), bg: theme.Mauve, minWidth: 7},
 	)
+	if len(v.jobs) > 0 {
+		pills = append(pills, pillSegment{text: v.viewJobs(), bg: theme.Peach, minWidth: 9})
+	}
+	pills = append(pills, pillSegment{text: sizeText, bg: theme.Sapphire, minWidth: 7})
+	info := renderPills(pills, theme.Base, theme.Surface0)
 
 	// Spacer to push info (and size) to the right
 	usedWidth := lipgloss.Width(left) + lipgloss.Width(info)
@@ -160,16 +195,28 @@ func (v *View) View() string {
 }
 
 func (v *View) viewSize() string {
-	size := float64(v.dirSize)
-	units := []string{" B", "KB", "MB", "GB", "TB"}
+	size, unit := util.ScaleSize(v.dirSize)
+	return fmt.Sprintf("%06.2f %s", size, unit)
+}
 
-	i := 0
-	for size >= 1024 && i < len(units)-1 {
-		size /= 1024
-		i++
+// viewJobs describes the running jobs. Several jobs are shown as their
+// combined progress.
+func (v *View) viewJobs() string {
+	var total filesys.ProgressStat
+	var kind filesys.JobKind
+	for id, stat := range v.jobs {
+		kind = v.jobKinds[id]
+		total.FilesDone += stat.FilesDone
+		total.FilesTotal += stat.FilesTotal
+		total.BytesDone += stat.BytesDone
+		total.BytesTotal += stat.BytesTotal
 	}
 
-	return fmt.Sprintf("%06.2f %s", size, units[i])
+	label := kind.String()
+	if len(v.jobs) > 1 {
+		label = fmt.Sprintf("%d jobs", len(v.jobs))
+	}
+	return fmt.Sprintf("%s %d%%", label, total.Percent())
 }
 
 func (v *View) viewSelection() string {
@@ -193,6 +240,8 @@ func (v *View) SetSelection(stats Stats) {
 	v.selIdx = stats.Index + 1
 	v.selTotal = stats.Total
 	v.selCount = stats.SelectionCount
+	v.clipOp = stats.ClipOp
+	v.clipCount = stats.ClipCount
 	v.selName = stats.Name
 	v.selMode = stats.Mode
 }
@@ -223,6 +272,17 @@ func (v *View) SetError(err error) tea.Cmd {
 	}
 }
 
+// SetInfo shows an informational message in place of the path for a few
+// seconds.
+func (v *View) SetInfo(text string) tea.Cmd {
+	v.info = text
+	v.infoAt = time.Now()
+	return func() tea.Msg {
+		time.Sleep(infoDur)
+		return struct{}{} // dummy message to trigger update
+	}
+}
+
 func (v *View) startSizeCalculation() tea.Cmd {
 	if v.cancel != nil {
 		v.cancel()
@@ -0,0 +1,194 @@
+package trashlist
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/alx99/sail/internal/filesys"
+	sstyle "github.com/alx99/sail/internal/style"
+	"github.com/alx99/sail/internal/ui/components/listcursor"
+	"github.com/alx99/sail/internal/ui/theme"
+	"github.com/alx99/sail/internal/util"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const dateLayout = "2006-01-02 15:04"
+
+// View lists the entries of the trash along with where they were deleted
+// from and when.
+type View struct {
+	entries []filesys.TrashEntry
+	marked  map[string]struct{}
+	// loaded is set once the entries have been listed.
+	loaded bool
+
+	sb        strings.Builder
+	maxHeight int
+	maxWidth  int
+	cursor    listcursor.Cursor
+}
+
+func New() *View {
+	return &View{marked: make(map[string]struct{})}
+}
+
+// Clear empties the list until the next SetEntries, so that nothing is
+// acted on while the trash is being listed again.
+func (v *View) Clear() {
+	v.entries = nil
+	clear(v.marked)
+	v.cursor.Reset()
+	v.loaded = false
+}
+
+// SetEntries replaces the listed entries, keeping the cursor on the same
+// entry if it still exists.
+func (v *View) SetEntries(entries []filesys.TrashEntry) {
+	curr, hadCurr := v.CurrEntry()
+
+	v.entries = entries
+	v.loaded = true
+
+	present := make(map[string]struct{}, len(entries))
+	for i, e := range entries {
+		present[e.Path] = struct{}{}
+		if hadCurr && e.Path == curr.Path {
+			v.cursor.Index = i
+		}
+	}
+	for path := range v.marked {
+		if _, ok := present[path]; !ok {
+			delete(v.marked, path)
+		}
+	}
+
+	v.cursor.Fix(len(v.entries), v.maxHeight)
+}
+
+func (v *View) SetMaxDims(rows, cols int) {
+	if rows <= 0 || cols <= 0 {
+		return
+	}
+	v.maxHeight = rows
+	v.maxWidth = cols
+	v.cursor.Fix(len(v.entries), v.maxHeight)
+}
+
+// MoveUp moves the cursor up, wrapping around at the top.
+func (v *View) MoveUp() {
+	v.cursor.Up(len(v.entries), v.maxHeight)
+}
+
+// MoveDown moves the cursor down, wrapping around at the bottom.
+func (v *View) MoveDown() {
+	v.cursor.Down(len(v.entries), v.maxHeight)
+}
+
+// ToggleMark marks or unmarks the entry under the cursor.
+func (v *View) ToggleMark() {
+	e, ok := v.CurrEntry()
+	if !ok {
+		return
+	}
+	if _, marked := v.marked[e.Path]; marked {
+		delete(v.marked, e.Path)
+	} else {
+		v.marked[e.Path] = struct{}{}
+	}
+}
+
+// Targets returns the marked entries, or the entry under the cursor if
+// nothing is marked.
+func (v *View) Targets() []filesys.TrashEntry {
+	var targets []filesys.TrashEntry
+	for _, e := range v.entries {
+		if _, ok := v.marked[e.Path]; ok {
+			targets = append(targets, e)
+		}
+	}
+	if len(targets) == 0 {
+		if e, ok := v.CurrEntry(); ok {
+			targets = append(targets, e)
+		}
+	}
+	return targets
+}
+
+func (v *View) Entries() []filesys.TrashEntry {
+	return v.entries
+}
+
+func (v *View) CurrEntry() (filesys.TrashEntry, bool) {
+	if v.cursor.Index < 0 || v.cursor.Index >= len(v.entries) {
+		return filesys.TrashEntry{}, false
+	}
+	return v.entries[v.cursor.Index], true
+}
+
+func (v *View) Position() (int, int) {
+	if len(v.entries) == 0 {
+		return 0, 0
+	}
+	return v.cursor.Index, len(v.entries)
+}
+
+func (v *View) View() string {
+	v.sb.Reset()
+
+	if len(v.entries) == 0 {
+		msg := theme.DefaultTheme.StatusInfo.Render("Trash is empty")
+		if !v.loaded {
+			msg = theme.DefaultTheme.StatusInfo.Render("Loading trash…")
+		}
+		return lipgloss.NewStyle().Width(v.maxWidth).Align(lipgloss.Center).Render(msg)
+	}
+
+	dateStyle := lipgloss.NewStyle().Foreground(theme.Overlay1)
+	origStyle := lipgloss.NewStyle().Foreground(theme.Overlay0)
+
+	end := min(v.cursor.Start+v.maxHeight, len(v.entries))
+	for i := v.cursor.Start; i < end; i++ {
+		e := v.entries[i]
+		_, marked := v.marked[e.Path]
+		isCursor := i == v.cursor.Index
+
+		nameStyle := lipgloss.NewStyle().Foreground(theme.Text)
+		if marked {
+			nameStyle = theme.DefaultTheme.SelectedFile
+		}
+		if isCursor {
+			nameStyle = nameStyle.Background(theme.Surface2).Bold(true)
+			dateStyle = dateStyle.Background(theme.Surface2)
+			origStyle = origStyle.Background(theme.Surface2)
+		}
+
+		date := ""
+		if !e.DeletedAt.IsZero() {
+			date = e.DeletedAt.Format(dateLayout)
+		}
+
+		icon := sstyle.GetIcon(e.Name(), false)
+		name := icon + " " + e.Name()
+		orig := " " + filepath.Dir(e.OrigPath)
+
+		// Name first, then as much of the original location as fits
+		avail := max(0, v.maxWidth-len(date)-1)
+		name = util.Truncate(name, avail)
+		orig = util.Truncate(orig, avail-lipgloss.Width(name))
+		pad := max(0, v.maxWidth-lipgloss.Width(name)-lipgloss.Width(orig)-len(date))
+
+		v.sb.WriteString(nameStyle.Render(name))
+		v.sb.WriteString(origStyle.Render(orig + strings.Repeat(" ", pad)))
+		v.sb.WriteString(dateStyle.Render(date))
+
+		if isCursor {
+			dateStyle = dateStyle.UnsetBackground()
+			origStyle = origStyle.UnsetBackground()
+		}
+		if i != end-1 {
+			v.sb.WriteString("\n")
+		}
+	}
+
+	return v.sb.String()
+}
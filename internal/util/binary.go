@@ -0,0 +1,13 @@
+package util
+
+import "bytes"
+
+// BinaryProbeSize is how much of the start of a file IsBinary looks at, the
+// same amount git checks.
+const BinaryProbeSize = 8000
+
+// IsBinary reports whether data, the start of a file, looks binary: it has
+// a NUL byte within its first BinaryProbeSize bytes.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), BinaryProbeSize)], 0) >= 0
+}
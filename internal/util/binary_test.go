@@ -0,0 +1,27 @@
+package util
+
+import "testing"
+
+func TestIsBinary(t *testing.T) {
+	late := make([]byte, BinaryProbeSize+1)
+	for i := range late {
+		late[i] = 'a'
+	}
+	late[BinaryProbeSize] = 0
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("hello\n"), false},
+		{"nul", []byte("a\x00b"), true},
+		{"nul past the probe", late, false},
+	}
+	for _, tt := range tests {
+		if got := IsBinary(tt.data); got != tt.want {
+			t.Errorf("%s: IsBinary = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package util
+
+// SafeRune returns r, or a question mark if r is a control character that
+// could mess up the terminal when printed.
+func SafeRune(r rune) rune {
+	if r < 0x20 || r >= 0x7f && r < 0xa0 {
+		return '?'
+	}
+	return r
+}
@@ -0,0 +1,16 @@
+package util
+
+// ScaleSize converts a size in bytes to the largest binary unit that keeps
+// the value at or above 1, returning the scaled value and its unit.
+func ScaleSize(bytes int64) (float64, string) {
+	size := float64(bytes)
+	units := []string{" B", "KB", "MB", "GB", "TB"}
+
+	i := 0
+	for size >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+
+	return size, units[i]
+}
@@ -0,0 +1,28 @@
+package util
+
+import "github.com/charmbracelet/x/ansi"
+
+// Truncate shortens s to at most width cells, replacing the end with an
+// ellipsis if anything was cut. Escape sequences in s are kept.
+func Truncate(s string, width int) string {
+	return ansi.Truncate(s, width, "…")
+}
+
+// TruncateLeft is Truncate for text whose end matters more than its start,
+// like paths, cutting the start instead.
+func TruncateLeft(s string, width int) string {
+	w := ansi.StringWidth(s)
+	if w <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	// A wide character straddling the cut is kept whole, so cut one more
+	// cell if it is
+	rest := ansi.TruncateLeft(s, w-width+1, "")
+	if ansi.StringWidth(rest) > width-1 {
+		rest = ansi.TruncateLeft(s, w-width+2, "")
+	}
+	return "…" + rest
+}
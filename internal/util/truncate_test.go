@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s           string
+		width       int
+		right, left string
+	}{
+		{"short", 10, "short", "short"},
+		{"/home/user/file", 8, "/home/u…", "…er/file"},
+		{"日本語abc", 5, "日本…", "…abc"},
+		{"anything", 0, "", ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.s, tt.width); got != tt.right {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.right)
+		}
+		if got := TruncateLeft(tt.s, tt.width); got != tt.left {
+			t.Errorf("TruncateLeft(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.left)
+		}
+		if w := ansi.StringWidth(TruncateLeft(tt.s, tt.width)); w > tt.width {
+			t.Errorf("TruncateLeft(%q, %d) is %d cells wide", tt.s, tt.width, w)
+		}
+	}
+}